@@ -0,0 +1,65 @@
+// Command crux serves the Remiges Crux rules and workflow engine over
+// HTTP.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/remiges-tech/crux/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	corsOrigins := flag.String("cors-origins", "", "comma-separated origins allowed to call the API from a browser")
+	corsCredentials := flag.Bool("cors-credentials", false, "allow credentialed cross-origin requests")
+	flag.Parse()
+
+	if *addr == "" {
+		slog.Error("empty -addr")
+		os.Exit(2)
+	}
+	s := server.New()
+	if *corsOrigins != "" {
+		origins := strings.Split(*corsOrigins, ",")
+		if err := validateOrigins(origins, *corsCredentials); err != nil {
+			slog.Error("invalid -cors-origins", "error", err)
+			os.Exit(2)
+		}
+		s.SetCORS(server.CORSConfig{
+			AllowedOrigins:   origins,
+			AllowCredentials: *corsCredentials,
+		})
+	}
+	slog.Info("serving", "addr", *addr)
+	if err := s.Run(*addr); err != nil {
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// validateOrigins rejects configured origins that a browser would never
+// send — anything but an http(s) scheme-and-host pair, or the "*"
+// wildcard — so a typo fails at startup rather than silently blocking
+// the authoring tool. The wildcard cannot be combined with credentialed
+// requests: the runtime policy would refuse it on every request, so the
+// combination fails here instead.
+func validateOrigins(origins []string, credentialed bool) error {
+	for _, origin := range origins {
+		if origin == "*" {
+			if credentialed {
+				return fmt.Errorf("origin \"*\" cannot be used with -cors-credentials; list concrete origins")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || u.Path != "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%q is not an http(s) origin", origin)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+module github.com/remiges-tech/crux
+
+go 1.21
@@ -0,0 +1,90 @@
+package crux
+
+import (
+	"fmt"
+	"time"
+)
+
+// Aggregate functions an AggregateSpec may apply to an event window.
+const (
+	aggCount = "count"
+	aggSum   = "sum"
+	aggAvg   = "avg"
+)
+
+// AggregateSpec makes an attribute's value a windowed aggregate over
+// events the caller supplies with the entity, enabling velocity rules
+// like "more than 5 logins in the last hour". Fn is one of count, sum
+// or avg; Window is how far back from the evaluation time events count.
+type AggregateSpec struct {
+	Fn     string
+	Window time.Duration
+}
+
+// AttrEvent is one timestamped observation feeding an aggregate
+// attribute. Val is ignored by count.
+type AttrEvent struct {
+	At  time.Time
+	Val float64
+}
+
+// verifyAggregateSpec checks an attribute's aggregate declaration: the
+// function must be known, the window positive, and the attribute typed
+// numerically so the computed value is comparable.
+func verifyAggregateSpec(as *AttrSchema) error {
+	spec := as.Aggregate
+	switch spec.Fn {
+	case aggCount, aggSum, aggAvg:
+	default:
+		return fmt.Errorf("attribute %q: unknown aggregate function %q", as.Name, spec.Fn)
+	}
+	if spec.Window <= 0 {
+		return fmt.Errorf("attribute %q: aggregate window must be positive", as.Name)
+	}
+	if as.ValType != typeInt && as.ValType != typeFloat {
+		return fmt.Errorf("attribute %q: aggregate attributes must be int or float, not %q", as.Name, as.ValType)
+	}
+	return nil
+}
+
+// computeAggregate applies the spec to the events falling inside the
+// window ending at asOf.
+func computeAggregate(events []AttrEvent, spec *AggregateSpec, asOf time.Time) float64 {
+	cutoff := asOf.Add(-spec.Window)
+	var n, sum float64
+	for _, ev := range events {
+		if ev.At.Before(cutoff) || ev.At.After(asOf) {
+			continue
+		}
+		n++
+		sum += ev.Val
+	}
+	switch spec.Fn {
+	case aggCount:
+		return n
+	case aggSum:
+		return sum
+	case aggAvg:
+		if n == 0 {
+			return 0
+		}
+		return sum / n
+	}
+	return 0
+}
+
+// evalAggregateTerm computes the aggregate value of the term's attribute
+// from the entity's event window and applies the term's comparison.
+func evalAggregateTerm(entity Entity, term *RulePatternTerm, as *AttrSchema) (bool, error) {
+	val := computeAggregate(entity.Events[term.Attr], as.Aggregate, time.Now().UTC())
+	var tv float64
+	switch v := term.Val.(type) {
+	case int:
+		tv = float64(v)
+	case float64:
+		tv = v
+	default:
+		return false, fmt.Errorf("attribute %q: aggregate comparison needs a numeric value", term.Attr)
+	}
+	return compareOrdered(val, tv, term.Op)
+}
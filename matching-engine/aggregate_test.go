@@ -0,0 +1,66 @@
+package crux
+
+import (
+	"testing"
+	"time"
+)
+
+// A count aggregate over the entity's event window drives a threshold
+// rule: six logins in the last hour trips "more than 5", while stale
+// events outside the window do not count.
+func TestAggregateThresholdRule(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{
+		Name:      "logins",
+		ValType:   typeInt,
+		Aggregate: &AggregateSpec{Fn: aggCount, Window: time.Hour},
+	})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "velocityset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "logins", Op: opGT, Val: 5}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+
+	now := time.Now().UTC()
+	entity := inventoryEntity()
+	entity.Events = map[string][]AttrEvent{"logins": {
+		{At: now.Add(-2 * time.Hour)}, // outside the window
+	}}
+	for i := 0; i < 6; i++ {
+		entity.Events["logins"] = append(entity.Events["logins"], AttrEvent{At: now.Add(-time.Minute * time.Duration(i))})
+	}
+
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("threshold rule did not fire on 6 logins in the window: %v", actionSet.Tasks)
+	}
+
+	entity.Events["logins"] = entity.Events["logins"][:4] // 3 in-window events left
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Fatalf("threshold rule fired below the threshold: %v", actionSet.Tasks)
+	}
+
+	// The spec itself is validated at save.
+	schema.PatternSchema[3].Aggregate.Fn = "median"
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Fatalf("unknown aggregate function accepted")
+	}
+}
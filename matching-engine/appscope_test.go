@@ -0,0 +1,70 @@
+package crux
+
+import "testing"
+
+// Two apps may declare the same class with different shapes; each
+// entity resolves against its own app's schema, falling back to the
+// realm-shared one only when the app has none.
+func TestAppScopedSchemaResolution(t *testing.T) {
+	shared := inventorySchema()
+	scoped := inventorySchema()
+	scoped.App = "retail"
+	scoped.PatternSchema = append(scoped.PatternSchema,
+		AttrSchema{Name: "aisle", ValType: typeStr})
+
+	savedSchemas, savedRuleSets := ruleSchemas, ruleSets
+	ruleSchemas = []*RuleSchema{shared, scoped}
+	ruleSets = map[string]*RuleSet{}
+	invalidateSchemaIndex()
+	defer func() {
+		ruleSchemas, ruleSets = savedSchemas, savedRuleSets
+		invalidateSchemaIndex()
+	}()
+
+	if got := scopedRuleSchema("retail", "inventoryitems"); got != scoped {
+		t.Errorf("scopedRuleSchema(retail) = %+v, want the app-scoped schema", got)
+	}
+	if got := scopedRuleSchema("lending", "inventoryitems"); got != shared {
+		t.Errorf("scopedRuleSchema(lending) = %+v, want the shared fallback", got)
+	}
+	if got := scopedRuleSchema("", "inventoryitems"); got != shared {
+		t.Errorf("scopedRuleSchema(shared) = %+v, want the shared schema", got)
+	}
+
+	// The aisle attribute only exists in retail's schema.
+	entity := inventoryEntity()
+	entity.App = "retail"
+	entity.Attrs["aisle"] = "b7"
+	if err := verifyEntity(entity); err != nil {
+		t.Errorf("retail entity with aisle: %v", err)
+	}
+	entity.App = ""
+	if err := verifyEntity(entity); err == nil {
+		t.Error("shared-scope entity with aisle attribute should fail verification")
+	}
+}
+
+// A THENCALL may stay within its app or target a shared ruleset, but
+// never cross into another app.
+func TestCrossAppCallRef(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+
+	rule := func(call string) []Rule {
+		return []Rule{{RuleActions: RuleActions{ThenCall: call}}}
+	}
+	shared := &RuleSet{Id: 1, Class: "inventoryitems", SetName: "sharedset"}
+	other := &RuleSet{Id: 2, App: "lending", Class: "inventoryitems", SetName: "lendingset"}
+	caller := &RuleSet{Id: 3, App: "retail", Class: "inventoryitems", SetName: "retailset",
+		Rules: rule("sharedset")}
+
+	if dangling := DoReferentialChecks([]*RuleSet{shared, other, caller}); len(dangling) != 0 {
+		t.Errorf("call into shared ruleset flagged: %+v", dangling)
+	}
+
+	caller.Rules = rule("lendingset")
+	dangling := DoReferentialChecks([]*RuleSet{shared, other, caller})
+	if len(dangling) != 1 || dangling[0].Kind != "crossapp" || dangling[0].Target != "lendingset" {
+		t.Errorf("cross-app call: got %+v, want one crossapp ref to lendingset", dangling)
+	}
+}
@@ -0,0 +1,54 @@
+package crux
+
+import "sort"
+
+// GetRuleSet returns a loaded ruleset by name.
+func GetRuleSet(setName string) (*RuleSet, bool) {
+	rs, ok := ruleSets[setName]
+	return rs, ok
+}
+
+// RequiredAttributes returns the deduplicated, sorted set of entity
+// attributes the ruleset's patterns reference, following THENCALL and
+// ELSECALL chains into other loaded rulesets. Callers use it to fetch
+// exactly the data an evaluation needs. Names that are really tasks or
+// properties emitted during evaluation are excluded, since the caller
+// cannot supply those.
+func RequiredAttributes(rs RuleSet) []string {
+	attrs := map[string]struct{}{}
+	seen := map[string]struct{}{}
+	collectRequiredAttrs(&rs, attrs, seen)
+	out := make([]string, 0, len(attrs))
+	for attr := range attrs {
+		out = append(out, attr)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func collectRequiredAttrs(rs *RuleSet, attrs map[string]struct{}, seen map[string]struct{}) {
+	if _, ok := seen[rs.SetName]; ok {
+		return
+	}
+	seen[rs.SetName] = struct{}{}
+	schema := schemaFor(rs)
+	for i := range rs.Rules {
+		for _, term := range rs.Rules[i].RulePatterns {
+			if schema != nil && getAttrSchema(schema, term.Attr) == nil {
+				continue // task or property, produced during evaluation
+			}
+			attrs[term.Attr] = struct{}{}
+		}
+		ra := &rs.Rules[i].RuleActions
+		if ra.ThenCall != "" {
+			if called, ok := ruleSets[ra.ThenCall]; ok {
+				collectRequiredAttrs(called, attrs, seen)
+			}
+		}
+		if ra.ElseCall != "" {
+			if called, ok := ruleSets[ra.ElseCall]; ok {
+				collectRequiredAttrs(called, attrs, seen)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package crux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequiredAttributesFollowsChains(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	ruleSets["pricing"] = &RuleSet{
+		Class: "inventoryitems", SetName: "pricing",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 100.0}},
+		}},
+	}
+	head := &RuleSet{
+		Class: "inventoryitems", SetName: "head",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{ThenCall: "pricing"},
+			},
+			{
+				// Tests a property set during evaluation; not a caller-supplied attribute.
+				RulePatterns: []RulePatternTerm{
+					{Attr: "shipby", Op: opEQ, Val: "air"},
+					{Attr: "cat", Op: opNE, Val: "notebook"},
+				},
+			},
+		},
+	}
+	ruleSets["head"] = head
+
+	got := RequiredAttributes(*head)
+	want := []string{"cat", "mrp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredAttributes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchEntityResult is the outcome for one entity of a batch match: the
+// action set it produced, or the error that kept it from producing one.
+// Index is the entity's position in the batch.
+type BatchEntityResult struct {
+	Index     int        `json:"index"`
+	ActionSet *ActionSet `json:"actionset,omitempty"`
+	Cached    bool       `json:"cached,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// MatchBatch evaluates many entities against one loaded ruleset. Each
+// entity is verified first; an entity that fails verification or
+// evaluation gets its error reported in its own slot rather than
+// aborting the batch. The int result counts the failed entities. The
+// context bounds every evaluation in the batch, so a caller deadline
+// cancels the remaining entities too.
+func MatchBatch(ctx context.Context, setName string, entities []Entity) ([]BatchEntityResult, int, error) {
+	return MatchBatchWithOptions(ctx, setName, entities, MatchOptions{})
+}
+
+// Match evaluates a single entity against one loaded ruleset, verifying
+// the entity first. It is the one-entity form of MatchBatch, for callers
+// like workflow stepping that evaluate on behalf of a single instance.
+// The context bounds the whole evaluation, lookups included, so a
+// request deadline set by the caller applies to the match itself.
+func Match(ctx context.Context, setName string, entity Entity) (ActionSet, error) {
+	return MatchWithOptions(ctx, setName, entity, MatchOptions{})
+}
+
+// MatchOptions carries the per-request tunables of one evaluation. The
+// zero value selects the engine defaults, so Match and MatchBatch are
+// just the zero-options forms.
+type MatchOptions struct {
+	// MaxFanOut caps the ruleset invocations this evaluation may make
+	// through THENCALL/ELSECALL chains; 0 keeps the engine default.
+	MaxFanOut int
+	// CorrelationID identifies the request this evaluation serves. It
+	// keys any sampled trace, so tracing needs it to be non-empty.
+	CorrelationID string
+	// CheckRequired fails the evaluation when the class's action schema
+	// declares required tasks the result does not carry.
+	CheckRequired bool
+}
+
+// config translates the exported options into the engine's internal
+// per-evaluation configuration.
+func (o MatchOptions) config() matchConfig {
+	return matchConfig{
+		maxFanOut:     o.MaxFanOut,
+		correlationID: o.CorrelationID,
+		tracePercent:  traceSamplePercent,
+		checkRequired: o.CheckRequired,
+	}
+}
+
+// MatchWithOptions is Match with per-request tunables.
+func MatchWithOptions(ctx context.Context, setName string, entity Entity, opts MatchOptions) (ActionSet, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return ActionSet{}, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return ActionSet{}, err
+	}
+	actionSet, _, _, err := doMatchCached(ctx, entity, rs,
+		ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, opts.config())
+	return actionSet, err
+}
+
+// MatchBatchWithOptions is MatchBatch with per-request tunables applied
+// to every entity of the batch.
+func MatchBatchWithOptions(ctx context.Context, setName string, entities []Entity, opts MatchOptions) ([]BatchEntityResult, int, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return nil, 0, fmt.Errorf("ruleset %q not found", setName)
+	}
+	results := make([]BatchEntityResult, len(entities))
+	failures := 0
+	for i, entity := range entities {
+		results[i].Index = i
+		entity, err := verifyAndConvertEntity(entity)
+		if err != nil {
+			results[i].Error = err.Error()
+			failures++
+			continue
+		}
+		actionSet, _, cached, err := doMatchCached(ctx, entity, rs,
+			ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, opts.config())
+		if err != nil {
+			results[i].Error = err.Error()
+			failures++
+			continue
+		}
+		results[i].ActionSet = &actionSet
+		results[i].Cached = cached
+	}
+	return results, failures, nil
+}
@@ -0,0 +1,40 @@
+package crux
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// The exported entry points honor the caller's context: a cancelled
+// request stops the evaluation instead of running it to completion.
+func TestMatchHonorsContext(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "ctxset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	if _, err := Match(context.Background(), "ctxset", inventoryEntity()); err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Match(cancelled, "ctxset", inventoryEntity()); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Errorf("Match with cancelled context: err = %v, want deadline error", err)
+	}
+	results, failures, err := MatchBatch(cancelled, "ctxset", []Entity{inventoryEntity()})
+	if err != nil {
+		t.Fatalf("MatchBatch: %v", err)
+	}
+	if failures != 1 || results[0].Error == "" {
+		t.Errorf("MatchBatch with cancelled context: failures=%d results=%+v", failures, results)
+	}
+}
@@ -0,0 +1,73 @@
+package crux
+
+import "fmt"
+
+// opBETWEEN matches when the attribute lies within an inclusive
+// [low, high] range, replacing the GE-plus-LE term pair authors would
+// otherwise have to keep in sync.
+const opBETWEEN = "between"
+
+// verifyBetweenTerm checks a range term at save: only ordered scalar
+// types may be ranged, the value must be a two-element [low, high]
+// list, both bounds must be legal values for the attribute, and low
+// must not exceed high.
+func verifyBetweenTerm(rs *RuleSet, ruleIdx int, term *RulePatternTerm, as *AttrSchema) error {
+	switch as.ValType {
+	case typeInt, typeFloat, typeTS, typeDate:
+	default:
+		return fmt.Errorf("ruleset %q rule %d: operator %q not valid for %s attribute %q",
+			rs.SetName, ruleIdx, term.Op, as.ValType, term.Attr)
+	}
+	bounds, ok := termValList(term.Val)
+	if !ok || len(bounds) != 2 {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: range term needs a [low, high] pair",
+			rs.SetName, ruleIdx, term.Attr)
+	}
+	for _, bound := range bounds {
+		boundTerm := RulePatternTerm{Attr: term.Attr, Op: opEQ, Val: bound}
+		if err := verifyTermVal(rs, ruleIdx, &boundTerm, as); err != nil {
+			return err
+		}
+	}
+	// An inverted range would silently match nothing.
+	inverted, err := boundsInverted(bounds[0], bounds[1], as)
+	if err != nil {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: %v", rs.SetName, ruleIdx, term.Attr, err)
+	}
+	if inverted {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: range low exceeds high",
+			rs.SetName, ruleIdx, term.Attr)
+	}
+	return nil
+}
+
+// boundsInverted reports whether low compares greater than high at the
+// attribute's type.
+func boundsInverted(low, high any, as *AttrSchema) (bool, error) {
+	lowTyped, err := convertEntityAttrVal(fmt.Sprintf("%v", low), as.ValType, as)
+	if err != nil {
+		return false, err
+	}
+	return evalTerm(lowTyped, opGT, high, as.ValType, nil)
+}
+
+// evalBetweenTerm tests the entity value against the term's inclusive
+// range, reusing the typed GE/LE comparisons.
+func evalBetweenTerm(entityVal string, term *RulePatternTerm, as *AttrSchema, coll CollatorFunc) (bool, error) {
+	if as == nil {
+		return false, fmt.Errorf("operator %q needs a schema-typed attribute", term.Op)
+	}
+	bounds, ok := termValList(term.Val)
+	if !ok || len(bounds) != 2 {
+		return false, fmt.Errorf("range term needs a [low, high] pair")
+	}
+	typed, err := convertEntityAttrVal(entityVal, as.ValType, as)
+	if err != nil {
+		return false, err
+	}
+	aboveLow, err := evalTerm(typed, opGE, bounds[0], as.ValType, coll)
+	if err != nil || !aboveLow {
+		return false, err
+	}
+	return evalTerm(typed, opLE, bounds[1], as.ValType, coll)
+}
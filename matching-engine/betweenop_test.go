@@ -0,0 +1,47 @@
+package crux
+
+import "testing"
+
+// A BETWEEN term matches inside its inclusive range; verification
+// rejects ranges on unordered types, inverted bounds and malformed
+// pairs.
+func TestBetweenOperator(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "rangeset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opBETWEEN, Val: []any{40.0, 60.0}}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	for mrp, want := range map[string]int{"50": 1, "40": 1, "60": 1, "39.9": 0, "60.1": 0} {
+		entity := inventoryEntity()
+		entity.Attrs["mrp"] = mrp
+		actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch mrp=%s: %v", mrp, err)
+		}
+		if len(actionSet.Tasks) != want {
+			t.Errorf("mrp=%s: got %d tasks, want %d", mrp, len(actionSet.Tasks), want)
+		}
+	}
+
+	bad := []RulePatternTerm{
+		{Attr: "cat", Op: opBETWEEN, Val: []any{"notebook", "textbook"}}, // enum
+		{Attr: "mrp", Op: opBETWEEN, Val: []any{60.0, 40.0}},             // inverted
+		{Attr: "mrp", Op: opBETWEEN, Val: []any{40.0}},                   // not a pair
+	}
+	for _, term := range bad {
+		rs.Rules[0].RulePatterns[0] = term
+		if err := verifyRuleSet(rs, false); err == nil {
+			t.Errorf("term %+v accepted at save", term)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resultCache memoizes evaluation results keyed by entity fingerprint,
+// ruleset name and ruleset id, so hot paths that re-evaluate identical
+// entities against unchanged rulesets skip the match entirely.
+type resultCache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	ttl        time.Duration
+	maxEntries int
+}
+
+type cacheEntry struct {
+	actionSet ActionSet
+	keepGoing bool
+	at        time.Time
+}
+
+// evalCache is the engine's result cache; nil until a caller enables it.
+var evalCache *resultCache
+
+// EnableResultCache turns result caching on with the given entry TTL and
+// size cap. Entries past the TTL are recomputed; when the cap is hit the
+// whole cache is dropped rather than tracking recency, which is cheap
+// and good enough for a cache of idempotent results.
+func EnableResultCache(ttl time.Duration, maxEntries int) {
+	evalCache = &resultCache{
+		entries:    map[string]cacheEntry{},
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// DisableResultCache turns result caching off and drops all entries.
+func DisableResultCache() {
+	evalCache = nil
+}
+
+// InvalidateCachedRuleSet drops every cached result of the named
+// ruleset; update paths call it so stale results never outlive a change.
+func InvalidateCachedRuleSet(setName string) {
+	if evalCache == nil {
+		return
+	}
+	evalCache.mu.Lock()
+	defer evalCache.mu.Unlock()
+	for key := range evalCache.entries {
+		if keyRuleSet(key) == setName {
+			delete(evalCache.entries, key)
+		}
+	}
+}
+
+// ReplaceRuleSet stores (or replaces) a loaded ruleset and invalidates
+// its cached results. A ruleset whose THENCALL/ELSECALL edges would
+// close a cycle through the loaded rulesets is rejected and nothing
+// changes.
+func ReplaceRuleSet(rs *RuleSet) error {
+	prev, had := ruleSets[rs.SetName]
+	ruleSets[rs.SetName] = rs
+	if err := checkCallCycle(nil); err != nil {
+		if had {
+			ruleSets[rs.SetName] = prev
+		} else {
+			delete(ruleSets, rs.SetName)
+		}
+		return err
+	}
+	InvalidateCachedRuleSet(rs.SetName)
+	publishChange(ChangeEvent{Realm: rs.Realm, Kind: changeKindRuleSet, Name: rs.SetName})
+	return nil
+}
+
+// doMatchCached is doMatchContext behind the result cache. The extra
+// bool reports whether the result came from the cache, for response
+// metadata. Match and MatchBatch route through it, so enabling the
+// cache covers all exported traffic.
+func doMatchCached(ctx context.Context, entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}, cfg matchConfig) (ActionSet, bool, bool, error) {
+	if evalCache == nil || !cacheableEntity(entity) {
+		res, keepGoing, err := doMatchContext(ctx, entity, ruleSet, actionSet, seenRuleSets, cfg)
+		return res, keepGoing, false, err
+	}
+	key := cacheKey(entity, ruleSet)
+	if entry, ok := evalCache.get(key); ok {
+		return copyActionSet(entry.actionSet), entry.keepGoing, true, nil
+	}
+	res, keepGoing, err := doMatchContext(ctx, entity, ruleSet, actionSet, seenRuleSets, cfg)
+	if err == nil {
+		evalCache.put(key, cacheEntry{actionSet: copyActionSet(res), keepGoing: keepGoing, at: time.Now()})
+	}
+	return res, keepGoing, false, err
+}
+
+// copyActionSet clones an ActionSet's tasks and properties, so cached
+// results never share their slice or map with a caller — a caller
+// mutating its result must not corrupt later hits.
+func copyActionSet(as ActionSet) ActionSet {
+	copied := ActionSet{}
+	if as.Tasks != nil {
+		copied.Tasks = append([]string(nil), as.Tasks...)
+	}
+	if as.Properties != nil {
+		copied.Properties = make(map[string]string, len(as.Properties))
+		for k, v := range as.Properties {
+			copied.Properties[k] = v
+		}
+	}
+	return copied
+}
+
+func (c *resultCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if c.ttl > 0 && time.Since(entry.at) > c.ttl {
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *resultCache) put(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.entries = map[string]cacheEntry{}
+	}
+	c.entries[key] = entry
+}
+
+// cacheableEntity reports whether an entity's evaluation may be served
+// from the cache. The fingerprint covers realm, app, class and the
+// attribute pairs; entities carrying event windows, child collections,
+// confidence or provenance would collide on it, and aggregate windows
+// and staleness checks are relative to the current time anyway, so such
+// entities always evaluate afresh.
+func cacheableEntity(entity Entity) bool {
+	return len(entity.Events) == 0 && len(entity.Children) == 0 &&
+		len(entity.Confidence) == 0 && len(entity.Provenance) == 0
+}
+
+// cacheKey combines the entity fingerprint with the ruleset's identity
+// and id, so a replaced ruleset naturally misses even before explicit
+// invalidation.
+func cacheKey(entity Entity, rs *RuleSet) string {
+	return fmt.Sprintf("%d|%d|%s", entityFingerprint(entity), rs.Id, rs.SetName)
+}
+
+// keyRuleSet extracts the set name component of a cache key.
+func keyRuleSet(key string) string {
+	parts := strings.SplitN(key, "|", 3)
+	return parts[len(parts)-1]
+}
+
+// entityFingerprint hashes the identity of a cacheable entity: realm,
+// app, class and the sorted attribute pairs. Entities whose evaluation
+// depends on more than these never reach the cache; see
+// cacheableEntity.
+func entityFingerprint(entity Entity) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(entity.Realm))
+	h.Write([]byte{0})
+	h.Write([]byte(entity.App))
+	h.Write([]byte{0})
+	h.Write([]byte(entity.Class))
+	names := make([]string, 0, len(entity.Attrs))
+	for name := range entity.Attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte{0})
+		h.Write([]byte(name))
+		h.Write([]byte{1})
+		h.Write([]byte(entity.Attrs[name]))
+	}
+	return h.Sum64()
+}
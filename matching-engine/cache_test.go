@@ -0,0 +1,109 @@
+package crux
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// A second identical evaluation is served from the cache, and replacing
+// the ruleset invalidates the cached result.
+func TestResultCache(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "cachedset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	EnableResultCache(time.Minute, 100)
+	defer DisableResultCache()
+	entity := inventoryEntity()
+
+	res, _, hit, err := doMatchCached(context.Background(), entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{})
+	if err != nil {
+		t.Fatalf("first evaluation: %v", err)
+	}
+	if hit {
+		t.Fatalf("first evaluation reported a cache hit")
+	}
+	if len(res.Tasks) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, _, hit, err = doMatchCached(context.Background(), entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{})
+	if err != nil {
+		t.Fatalf("second evaluation: %v", err)
+	}
+	if !hit {
+		t.Fatalf("identical second evaluation was not served from cache")
+	}
+	if len(res.Tasks) != 1 {
+		t.Fatalf("cached result differs: %+v", res)
+	}
+
+	// A different entity must miss.
+	other := inventoryEntity()
+	other.Attrs["mrp"] = "60"
+	if _, _, hit, _ := doMatchCached(context.Background(), other, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{}); hit {
+		t.Fatalf("different entity hit the cache")
+	}
+
+	// A hit hands back its own copy: mutating one caller's result must
+	// not corrupt what the next hit sees.
+	res.Tasks[0] = "mangled"
+	if res.Properties != nil {
+		res.Properties["mangled"] = "true"
+	}
+	res, _, hit, err = doMatchCached(context.Background(), entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{})
+	if err != nil || !hit {
+		t.Fatalf("third evaluation: hit=%v err=%v", hit, err)
+	}
+	if len(res.Tasks) != 1 || res.Tasks[0] != "invitefordiwali" {
+		t.Fatalf("a caller's mutation reached the cache: %+v", res)
+	}
+
+	// Entities whose evaluation depends on more than the attribute pairs
+	// — confidence, provenance, event windows, child collections — must
+	// not share a cached result with an attrs-identical entity.
+	confident := inventoryEntity()
+	confident.Confidence = map[string]float64{"cat": 0.1}
+	if _, _, hit, _ := doMatchCached(context.Background(), confident, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{}); hit {
+		t.Fatalf("entity with confidence hit the attrs-only cache entry")
+	}
+	withChildren := inventoryEntity()
+	withChildren.Children = map[string][]Entity{"lines": {{Class: "inventoryitems"}}}
+	if _, _, hit, _ := doMatchCached(context.Background(), withChildren, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{}); hit {
+		t.Fatalf("entity with a child collection hit the attrs-only cache entry")
+	}
+
+	// The exported batch path reports the hit in its per-entity result.
+	results, _, err := MatchBatch(context.Background(), "cachedset", []Entity{inventoryEntity()})
+	if err != nil {
+		t.Fatalf("MatchBatch: %v", err)
+	}
+	if !results[0].Cached {
+		t.Errorf("batch result did not report the cache hit")
+	}
+
+	// Updating the ruleset drops its cached results.
+	updated := *rs
+	updated.Rules = nil
+	ReplaceRuleSet(&updated)
+	res, _, hit, err = doMatchCached(context.Background(), entity, &updated, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{})
+	if err != nil {
+		t.Fatalf("evaluation after update: %v", err)
+	}
+	if hit {
+		t.Fatalf("cache served a result across a ruleset update")
+	}
+	if len(res.Tasks) != 0 {
+		t.Fatalf("stale result after update: %+v", res)
+	}
+}
@@ -0,0 +1,79 @@
+package crux
+
+import "fmt"
+
+// DetectCallCycle searches the directed graph of THENCALL/ELSECALL
+// edges — across the loaded rulesets plus the candidates — for a cycle,
+// and returns the ruleset names forming the first one found, in call
+// order. It returns nil when the graph is acyclic. The evaluator's
+// seenRuleSets guard catches a cycle at runtime too, but only after the
+// bad ruleset has shipped; import and save flows run this up front.
+func DetectCallCycle(candidates []*RuleSet) []string {
+	graph := map[string][]string{}
+	addEdges := func(rs *RuleSet) {
+		for i := range rs.Rules {
+			ra := &rs.Rules[i].RuleActions
+			if ra.ThenCall != "" {
+				graph[rs.SetName] = append(graph[rs.SetName], ra.ThenCall)
+			}
+			if ra.ElseCall != "" {
+				graph[rs.SetName] = append(graph[rs.SetName], ra.ElseCall)
+			}
+		}
+	}
+	for _, rs := range ruleSets {
+		addEdges(rs)
+	}
+	for _, rs := range candidates {
+		addEdges(rs)
+	}
+
+	// DFS with three colors: white (unvisited), gray (on the current
+	// path), black (done). A gray target closes a cycle.
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := map[string]int{}
+	var path []string
+	var cycle []string
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		color[name] = gray
+		path = append(path, name)
+		for _, target := range graph[name] {
+			switch color[target] {
+			case gray:
+				for i, n := range path {
+					if n == target {
+						cycle = append([]string{}, path[i:]...)
+						return true
+					}
+				}
+			case white:
+				if visit(target) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		color[name] = black
+		return false
+	}
+	for name := range graph {
+		if color[name] == white && visit(name) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// checkCallCycle wraps DetectCallCycle for the save path: a non-nil
+// result becomes an error naming the rulesets in the cycle.
+func checkCallCycle(candidates []*RuleSet) error {
+	if cycle := DetectCallCycle(candidates); cycle != nil {
+		return fmt.Errorf("THENCALL/ELSECALL cycle through rulesets %v", cycle)
+	}
+	return nil
+}
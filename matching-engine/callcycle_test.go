@@ -0,0 +1,43 @@
+package crux
+
+import "testing"
+
+// A THENCALL/ELSECALL cycle among candidates is detected and named, and
+// ReplaceRuleSet refuses a ruleset that would close a cycle through the
+// loaded graph.
+func TestDetectCallCycle(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+
+	candidates := []*RuleSet{
+		{Class: "inventoryitems", SetName: "cyca",
+			Rules: []Rule{{RuleActions: RuleActions{ThenCall: "cycb"}}}},
+		{Class: "inventoryitems", SetName: "cycb",
+			Rules: []Rule{{RuleActions: RuleActions{ElseCall: "cyca"}}}},
+	}
+	cycle := DetectCallCycle(candidates)
+	if len(cycle) != 2 {
+		t.Fatalf("cycle = %v, want the cyca/cycb loop", cycle)
+	}
+
+	// An acyclic chain passes.
+	candidates[1].Rules[0].RuleActions.ElseCall = ""
+	if cycle := DetectCallCycle(candidates); cycle != nil {
+		t.Errorf("cycle = %v for an acyclic graph", cycle)
+	}
+
+	if err := ReplaceRuleSet(candidates[0]); err != nil {
+		t.Fatalf("ReplaceRuleSet cyca: %v", err)
+	}
+	if err := ReplaceRuleSet(candidates[1]); err != nil {
+		t.Fatalf("ReplaceRuleSet cycb: %v", err)
+	}
+	closing := &RuleSet{Class: "inventoryitems", SetName: "cycb",
+		Rules: []Rule{{RuleActions: RuleActions{ThenCall: "cyca"}}}}
+	if err := ReplaceRuleSet(closing); err == nil {
+		t.Fatalf("ruleset closing a call cycle accepted")
+	}
+	if ruleSets["cycb"] != candidates[1] {
+		t.Errorf("rejected replacement modified the loaded rulesets")
+	}
+}
@@ -0,0 +1,52 @@
+package crux
+
+import "fmt"
+
+// canonicalTables holds canonicalization tables (synonym -> canonical
+// form) that string attributes may reference by name, so dirty inputs
+// like "USA"/"United States"/"US" collapse to one value before matching.
+var canonicalTables = map[string]map[string]string{}
+
+// RegisterCanonicalTable registers a canonicalization table under a name
+// that attribute schemas can reference through their Canonical field.
+func RegisterCanonicalTable(name string, table map[string]string) {
+	canonicalTables[name] = table
+}
+
+// canonicalizeVal maps a value through the attribute's canonicalization
+// table, if it declares one; values without a synonym entry pass through
+// unchanged.
+func canonicalizeVal(val string, as *AttrSchema) string {
+	if as == nil || as.Canonical == "" {
+		return val
+	}
+	if canonical, ok := canonicalTables[as.Canonical][val]; ok {
+		return canonical
+	}
+	return val
+}
+
+// verifyCanonicalRef checks an attribute's canonicalization reference at
+// schema-save time: the table must be registered, only string-ish
+// attributes may canonicalize, and for enums every canonical form must
+// be a permitted value.
+func verifyCanonicalRef(as *AttrSchema) error {
+	if as.Canonical == "" {
+		return nil
+	}
+	if as.ValType != typeStr && as.ValType != typeEnum {
+		return fmt.Errorf("attribute %q of type %q cannot use a canonicalization table", as.Name, as.ValType)
+	}
+	table, ok := canonicalTables[as.Canonical]
+	if !ok {
+		return fmt.Errorf("attribute %q references unregistered canonicalization table %q", as.Name, as.Canonical)
+	}
+	if as.ValType == typeEnum {
+		for synonym, canonical := range table {
+			if _, ok := as.Vals[canonical]; !ok {
+				return fmt.Errorf("attribute %q: table %q maps %q to %q, which is not a permitted value", as.Name, as.Canonical, synonym, canonical)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package crux
+
+import "testing"
+
+func TestCanonicalizationBeforeMatching(t *testing.T) {
+	RegisterCanonicalTable("countries", map[string]string{
+		"USA":           "us",
+		"United States": "us",
+		"US":            "us",
+	})
+	defer delete(canonicalTables, "countries")
+
+	schema := &RuleSchema{
+		Class: "customer",
+		PatternSchema: []AttrSchema{
+			{Name: "country", ValType: typeStr, Canonical: "countries"},
+		},
+		ActionSchema: ActionSchema{Tasks: []string{"domesticrate"}},
+	}
+	rs := &RuleSet{
+		Class:   "customer",
+		SetName: "rates",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "country", Op: opEQ, Val: "us"}},
+			RuleActions:  RuleActions{Tasks: []string{"domesticrate"}},
+		}},
+	}
+	defer setupTestSchema(t, schema, rs)()
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+
+	for _, synonym := range []string{"USA", "United States", "US"} {
+		e := Entity{Class: "customer", Attrs: map[string]string{"country": synonym}}
+		actionSet, _, err := doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch(%q): %v", synonym, err)
+		}
+		if len(actionSet.Tasks) != 1 {
+			t.Errorf("synonym %q did not match the canonical rule", synonym)
+		}
+	}
+}
+
+func TestCanonicalRefValidatedAtSave(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "customer",
+		PatternSchema: []AttrSchema{
+			{Name: "country", ValType: typeStr, Canonical: "nosuchtable"},
+		},
+	}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted unregistered canonicalization table")
+	}
+
+	RegisterCanonicalTable("sizes", map[string]string{"Large": "XL"})
+	defer delete(canonicalTables, "sizes")
+	schema.PatternSchema[0] = AttrSchema{
+		Name: "size", ValType: typeEnum,
+		Vals:      map[string]struct{}{"s": {}, "m": {}},
+		Canonical: "sizes",
+	}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted table mapping to non-enum value")
+	}
+}
@@ -0,0 +1,32 @@
+package crux
+
+import "strings"
+
+// foldedTerm returns the term with its string values lower-cased, for
+// case-insensitive comparison. The entity-side operand is lowered by
+// the caller; together the two make every comparison — equality,
+// membership, substring — case-blind without per-operator variants.
+func foldedTerm(term *RulePatternTerm) *RulePatternTerm {
+	folded := *term
+	switch v := term.Val.(type) {
+	case string:
+		folded.Val = strings.ToLower(v)
+	case []string:
+		lowered := make([]string, len(v))
+		for i, s := range v {
+			lowered[i] = strings.ToLower(s)
+		}
+		folded.Val = lowered
+	case []any:
+		lowered := make([]any, len(v))
+		for i, e := range v {
+			if s, ok := e.(string); ok {
+				lowered[i] = strings.ToLower(s)
+			} else {
+				lowered[i] = e
+			}
+		}
+		folded.Val = lowered
+	}
+	return &folded
+}
@@ -0,0 +1,69 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+// A case-insensitive term matches across casings; the flag is rejected
+// at save on non-string attributes; rule terms still spell enum values
+// in their declared casing.
+func TestCaseInsensitiveTerms(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "supplier", ValType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "foldset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{
+				{Attr: "supplier", Op: opEQ, Val: "Acme Books", CaseInsensitive: true},
+				{Attr: "cat", Op: opEQ, Val: "textbook", CaseInsensitive: true},
+			},
+			RuleActions: RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	// Through the exported path too, where entity attributes carry
+	// pre-converted typed values that must not defeat the folding.
+	for supplier, want := range map[string]int{"ACME BOOKS": 1, "acme books": 1, "apex books": 0} {
+		entity := inventoryEntity()
+		entity.Attrs["supplier"] = supplier
+		actionSet, err := Match(context.Background(), "foldset", entity)
+		if err != nil {
+			t.Fatalf("Match supplier=%q: %v", supplier, err)
+		}
+		if len(actionSet.Tasks) != want {
+			t.Errorf("supplier=%q: got %d tasks, want %d", supplier, len(actionSet.Tasks), want)
+		}
+	}
+
+	rs.Rules[0].RulePatterns = []RulePatternTerm{
+		{Attr: "mrp", Op: opGT, Val: 10.0, CaseInsensitive: true},
+	}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("caseinsensitive accepted on a float attribute")
+	}
+
+	// A regex term cannot be folded: lower-casing the pattern text
+	// would corrupt it, so the combination is rejected at save.
+	rs.Rules[0].RulePatterns = []RulePatternTerm{
+		{Attr: "supplier", Op: opMATCH, Val: `^[A-Z]+$`, CaseInsensitive: true},
+	}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("caseinsensitive accepted on a regex term")
+	}
+
+	// Enum term values keep their declared casing at save time.
+	rs.Rules[0].RulePatterns = []RulePatternTerm{
+		{Attr: "cat", Op: opEQ, Val: "Textbook", CaseInsensitive: true},
+	}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("enum term value accepted in undeclared casing")
+	}
+}
@@ -0,0 +1,137 @@
+package crux
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Functions a ChildSpec may apply across a child collection.
+const (
+	childExists = "exists"
+	childCount  = "count"
+	childSum    = "sum"
+)
+
+// ChildSpec makes an attribute's value an aggregate over one of the
+// entity's child collections, enabling parent-child rules like "has any
+// transaction over 10k" without flattening the children into the parent.
+// Where is the sub-condition a child must satisfy to be counted,
+// verified against the child class's schema; Of names the child
+// attribute summed by the sum function.
+type ChildSpec struct {
+	Collection string
+	Class      string
+	Fn         string
+	Of         string
+	Where      []RulePatternTerm
+}
+
+// verifyChildSpec checks an attribute's child-aggregate declaration at
+// save: the function and result type must agree, and the sub-condition
+// must reference declared attributes of the child class with legal
+// operators.
+func verifyChildSpec(as *AttrSchema) error {
+	spec := as.Child
+	switch spec.Fn {
+	case childExists:
+		if as.ValType != typeBool {
+			return fmt.Errorf("attribute %q: exists child-aggregates must be bool, not %q", as.Name, as.ValType)
+		}
+	case childCount:
+		if as.ValType != typeInt {
+			return fmt.Errorf("attribute %q: count child-aggregates must be int, not %q", as.Name, as.ValType)
+		}
+	case childSum:
+		if as.ValType != typeFloat {
+			return fmt.Errorf("attribute %q: sum child-aggregates must be float, not %q", as.Name, as.ValType)
+		}
+	default:
+		return fmt.Errorf("attribute %q: unknown child-aggregate function %q", as.Name, spec.Fn)
+	}
+	if spec.Collection == "" {
+		return fmt.Errorf("attribute %q: child-aggregate names no collection", as.Name)
+	}
+	childSchema := getRuleSchema(spec.Class)
+	if childSchema == nil {
+		return fmt.Errorf("attribute %q: no schema found for child class %q", as.Name, spec.Class)
+	}
+	if spec.Fn == childSum {
+		ofSchema := getAttrSchema(childSchema, spec.Of)
+		if ofSchema == nil || (ofSchema.ValType != typeInt && ofSchema.ValType != typeFloat) {
+			return fmt.Errorf("attribute %q: sum child-aggregate needs a numeric child attribute, not %q", as.Name, spec.Of)
+		}
+	}
+	for _, term := range spec.Where {
+		if getAttrSchema(childSchema, term.Attr) == nil {
+			return fmt.Errorf("attribute %q: sub-condition references attribute %q not in child class %q", as.Name, term.Attr, spec.Class)
+		}
+		if _, ok := validOps[term.Op]; !ok {
+			return fmt.Errorf("attribute %q: sub-condition uses unknown operator %q", as.Name, term.Op)
+		}
+	}
+	return nil
+}
+
+// computeChildAggregate applies the spec across the entity's named child
+// collection, counting only the children whose attributes satisfy the
+// sub-condition.
+func computeChildAggregate(entity Entity, spec *ChildSpec) (float64, error) {
+	childSchema := getRuleSchema(spec.Class)
+	if childSchema == nil {
+		return 0, fmt.Errorf("no schema found for child class %q", spec.Class)
+	}
+	var n, sum float64
+	for _, child := range entity.Children[spec.Collection] {
+		holds, _, err := matchPattern(child, spec.Where, ActionSet{}, childSchema)
+		if err != nil {
+			return 0, err
+		}
+		if !holds {
+			continue
+		}
+		n++
+		if spec.Fn == childSum {
+			v, err := strconv.ParseFloat(child.Attrs[spec.Of], 64)
+			if err != nil {
+				return 0, fmt.Errorf("child attribute %q: %q is not numeric", spec.Of, child.Attrs[spec.Of])
+			}
+			sum += v
+		}
+	}
+	if spec.Fn == childSum {
+		return sum, nil
+	}
+	return n, nil
+}
+
+// evalChildTerm computes the child aggregate for the term's attribute
+// and applies the term's comparison.
+func evalChildTerm(entity Entity, term *RulePatternTerm, as *AttrSchema) (bool, error) {
+	val, err := computeChildAggregate(entity, as.Child)
+	if err != nil {
+		return false, fmt.Errorf("attribute %q: %v", term.Attr, err)
+	}
+	if as.Child.Fn == childExists {
+		tv, ok := term.Val.(bool)
+		if !ok {
+			return false, fmt.Errorf("attribute %q: exists comparison needs a bool value", term.Attr)
+		}
+		switch term.Op {
+		case opEQ:
+			return (val > 0) == tv, nil
+		case opNE:
+			return (val > 0) != tv, nil
+		}
+		return false, fmt.Errorf("attribute %q: operator %q not valid for exists", term.Attr, term.Op)
+	}
+	var tv float64
+	switch v := term.Val.(type) {
+	case int:
+		tv = float64(v)
+	case float64:
+		tv = v
+	default:
+		return false, fmt.Errorf("attribute %q: child-aggregate comparison needs a numeric value", term.Attr)
+	}
+	return compareOrdered(val, tv, term.Op)
+}
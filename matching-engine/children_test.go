@@ -0,0 +1,79 @@
+package crux
+
+import "testing"
+
+// A parent matches on an aggregate over its child transactions: here,
+// whether any transaction over 10k exists, and how many there are.
+func TestChildAggregateMatch(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "haslargetxn", ValType: typeBool, Child: &ChildSpec{
+			Collection: "transactions",
+			Class:      "transactions",
+			Fn:         childExists,
+			Where:      []RulePatternTerm{{Attr: "amount", Op: opGT, Val: 10000.0}},
+		}},
+		AttrSchema{Name: "txncount", ValType: typeInt, Child: &ChildSpec{
+			Collection: "transactions",
+			Class:      "transactions",
+			Fn:         childCount,
+		}},
+	)
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "childset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{
+				{Attr: "haslargetxn", Op: opEQ, Val: true},
+				{Attr: "txncount", Op: opGE, Val: 2},
+			},
+			RuleActions: RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	childSchema := &RuleSchema{
+		Class: "transactions",
+		PatternSchema: []AttrSchema{
+			{Name: "amount", ValType: typeFloat},
+		},
+	}
+	if err := SaveRuleSchema(childSchema, false); err != nil {
+		t.Fatalf("SaveRuleSchema(child): %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Children = map[string][]Entity{
+		"transactions": {
+			{Class: "transactions", Attrs: map[string]string{"amount": "15000"}},
+			{Class: "transactions", Attrs: map[string]string{"amount": "200"}},
+		},
+	}
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("parent did not match on child aggregates: %+v", actionSet)
+	}
+
+	entity.Children["transactions"] = entity.Children["transactions"][1:]
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("parent matched without a qualifying child: %+v", actionSet)
+	}
+
+	badSchema := inventorySchema()
+	badSchema.PatternSchema = append(badSchema.PatternSchema, AttrSchema{
+		Name: "badagg", ValType: typeBool,
+		Child: &ChildSpec{Collection: "transactions", Class: "transactions", Fn: childExists,
+			Where: []RulePatternTerm{{Attr: "nosuchattr", Op: opEQ, Val: "x"}}},
+	})
+	if err := verifyRuleSchema(badSchema, false); err == nil {
+		t.Fatalf("sub-condition on undeclared child attribute accepted at save")
+	}
+}
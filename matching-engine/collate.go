@@ -0,0 +1,73 @@
+package crux
+
+import "fmt"
+
+// CollatorFunc orders two strings under some locale's collation rules,
+// returning a negative number, zero or a positive number as a sorts
+// before, equal to or after b.
+type CollatorFunc func(a, b string) int
+
+// collators holds the collations callers have registered, keyed by
+// locale tag (e.g. "fr", "de-phonebook").
+var collators = map[string]CollatorFunc{}
+
+// RegisterCollator makes a locale's collation available for realms to
+// select. The engine ships none itself; callers typically wrap
+// golang.org/x/text/collate here.
+func RegisterCollator(locale string, fn CollatorFunc) error {
+	if locale == "" || fn == nil {
+		return fmt.Errorf("collator needs a locale and a function")
+	}
+	collators[locale] = fn
+	return nil
+}
+
+// realmCollations maps a realm to the locale its string comparisons
+// collate under. Realms without an entry use byte ordering.
+var realmCollations = map[string]string{}
+
+// SetRealmCollation selects the collation locale for a realm's string
+// comparisons, rejecting locales no collator is registered for. An empty
+// locale restores byte ordering.
+func SetRealmCollation(realm, locale string) error {
+	if locale == "" {
+		delete(realmCollations, realm)
+		return nil
+	}
+	if _, ok := collators[locale]; !ok {
+		return fmt.Errorf("no collator registered for locale %q", locale)
+	}
+	realmCollations[realm] = locale
+	return nil
+}
+
+// collatorForRealm resolves the realm's collator; nil means byte order.
+func collatorForRealm(realm string) CollatorFunc {
+	if locale, ok := realmCollations[realm]; ok {
+		return collators[locale]
+	}
+	return nil
+}
+
+// compareStrings applies an operator to two strings, under the given
+// collation when one is set and byte order otherwise.
+func compareStrings(a, b, op string, coll CollatorFunc) (bool, error) {
+	if coll != nil {
+		return compareOrdered(float64(coll(a, b)), 0, op)
+	}
+	switch op {
+	case opEQ:
+		return a == b, nil
+	case opNE:
+		return a != b, nil
+	case opLT:
+		return a < b, nil
+	case opLE:
+		return a <= b, nil
+	case opGT:
+		return a > b, nil
+	case opGE:
+		return a >= b, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
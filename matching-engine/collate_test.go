@@ -0,0 +1,69 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+// foldAccents is a toy collation for tests: accented letters sort with
+// their base letter, unlike byte order where they sort after all ASCII.
+func foldAccents(a, b string) int {
+	r := strings.NewReplacer("é", "e", "è", "e", "à", "a", "ü", "u")
+	return strings.Compare(r.Replace(a), r.Replace(b))
+}
+
+// Under byte ordering "émile" sorts after "fred"; under a locale that
+// folds accents it sorts before. The realm's collation must decide.
+func TestRealmCollation(t *testing.T) {
+	if err := RegisterCollator("fr-test", foldAccents); err != nil {
+		t.Fatalf("RegisterCollator: %v", err)
+	}
+	if err := SetRealmCollation("acme", "nosuchlocale"); err == nil {
+		t.Fatalf("collation set to an unregistered locale")
+	}
+	if err := SetRealmCollation("acme", "fr-test"); err != nil {
+		t.Fatalf("SetRealmCollation: %v", err)
+	}
+	defer SetRealmCollation("acme", "")
+
+	byteOrder, err := compareStrings("émile", "fred", opLT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byteOrder {
+		t.Fatalf("byte order unexpectedly puts %q before %q", "émile", "fred")
+	}
+	collated, err := compareStrings("émile", "fred", opLT, collatorForRealm("acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !collated {
+		t.Fatalf("collated order should put %q before %q", "émile", "fred")
+	}
+
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "supplier", ValType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "collatedset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "supplier", Op: opLT, Val: "fred"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	entity := inventoryEntity()
+	entity.Realm = "acme"
+	entity.Attrs["supplier"] = "émile"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("rule did not fire under the realm's collation: %v", actionSet.Tasks)
+	}
+}
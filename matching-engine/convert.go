@@ -0,0 +1,176 @@
+package crux
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayout is the wire format for typeTS attribute values.
+const timeLayout = time.RFC3339
+
+// defaultMaxMagnitude bounds the absolute value of numeric inputs when
+// the attribute schema does not declare its own limit. Values beyond it
+// are almost always garbage, and large magnitudes lose float precision.
+const defaultMaxMagnitude = 1e15
+
+// maxNumericMagnitude is the engine-wide magnitude bound, adjustable via
+// SetMaxNumericMagnitude. Zero disables the check.
+var maxNumericMagnitude = float64(defaultMaxMagnitude)
+
+// SetMaxNumericMagnitude changes the engine-wide bound on the absolute
+// value of numeric attribute inputs; 0 disables it. Returns the previous
+// bound so callers can restore it.
+func SetMaxNumericMagnitude(limit float64) float64 {
+	prev := maxNumericMagnitude
+	maxNumericMagnitude = limit
+	return prev
+}
+
+// magnitudeLimit resolves the magnitude bound for an attribute: its own
+// MaxMagnitude when declared, else the engine-wide bound.
+func magnitudeLimit(as *AttrSchema) float64 {
+	if as != nil && as.MaxMagnitude > 0 {
+		return as.MaxMagnitude
+	}
+	return maxNumericMagnitude
+}
+
+// checkMagnitude rejects numeric values whose absolute value exceeds
+// limit; a limit of 0 means unbounded.
+func checkMagnitude(v float64, limit float64) error {
+	if limit > 0 && math.Abs(v) > limit {
+		return fmt.Errorf("value %v exceeds the maximum magnitude %v", v, limit)
+	}
+	return nil
+}
+
+// convertEntityAttrVal converts the string form of an attribute value to
+// its typed Go value as dictated by valType. Numeric values beyond the
+// attribute's magnitude limit (see magnitudeLimit) are rejected; as may
+// be nil when no schema applies.
+func convertEntityAttrVal(val string, valType string, as *AttrSchema) (any, error) {
+	switch valType {
+	case typeBool:
+		return parseLooseBool(val)
+	case typeInt:
+		n, err := parseLooseInt(val)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkMagnitude(float64(n), magnitudeLimit(as)); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case typeFloat:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkMagnitude(f, magnitudeLimit(as)); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case typeStr, typeEnum:
+		return val, nil
+	case typeTS:
+		return time.Parse(timeLayout, val)
+	case typeDate:
+		return time.Parse(dateLayout, val)
+	case typeList:
+		return parseListVal(val, as)
+	}
+	return nil, fmt.Errorf("unknown value type %q", valType)
+}
+
+// verifyType checks that val is a legal value for the attribute described
+// by as, including range, length and enum-membership constraints.
+func verifyType(val string, as *AttrSchema) error {
+	switch as.ValType {
+	case typeBool:
+		if _, err := parseLooseBool(val); err != nil {
+			return fmt.Errorf("attribute %q: %v", as.Name, err)
+		}
+	case typeInt:
+		n, err := parseLooseInt(val)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %v", as.Name, err)
+		}
+		if err := checkRange(as, float64(n)); err != nil {
+			return err
+		}
+	case typeFloat:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %q is not a number", as.Name, val)
+		}
+		if err := checkRange(as, f); err != nil {
+			return err
+		}
+	case typeStr:
+		if as.LenMax > 0 && (len(val) < as.LenMin || len(val) > as.LenMax) {
+			return fmt.Errorf("attribute %q: length of %q outside [%d,%d]", as.Name, val, as.LenMin, as.LenMax)
+		}
+	case typeEnum:
+		if _, ok := as.Vals[canonicalizeVal(val, as)]; !ok {
+			return fmt.Errorf("attribute %q: %q is not a permitted value", as.Name, val)
+		}
+	case typeTS:
+		if _, err := time.Parse(timeLayout, val); err != nil {
+			return fmt.Errorf("attribute %q: %q is not a timestamp", as.Name, val)
+		}
+	case typeDate:
+		if _, err := time.Parse(dateLayout, val); err != nil {
+			return fmt.Errorf("attribute %q: %q is not a date", as.Name, val)
+		}
+	case typeList:
+		if _, err := parseListVal(val, as); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("attribute %q: unknown value type %q", as.Name, as.ValType)
+	}
+	return nil
+}
+
+// parseLooseInt parses an integer value, tolerating the float spelling
+// a JSON encoder gives whole numbers ("42.0"): values that round-trip
+// through float64 on the way to us must still verify. Genuinely
+// fractional values are rejected.
+func parseLooseInt(val string) (int, error) {
+	if n, err := strconv.Atoi(val); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", val)
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%q has a fractional part; not an integer", val)
+	}
+	return int(f), nil
+}
+
+// parseLooseBool normalizes the boolean spellings producers actually
+// send — "true"/"false", "1"/"0", "yes"/"no" and their shorthands, any
+// case — and rejects anything ambiguous with a clear error.
+func parseLooseBool(val string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "true", "t", "1", "yes", "y":
+		return true, nil
+	case "false", "f", "0", "no", "n":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not an unambiguous boolean", val)
+}
+
+// checkRange enforces ValMin/ValMax on a numeric value when the schema
+// declares a range (ValMax > ValMin).
+func checkRange(as *AttrSchema, v float64) error {
+	if as.ValMax > as.ValMin && (v < as.ValMin || v > as.ValMax) {
+		return fmt.Errorf("attribute %q: value %v outside [%v,%v]", as.Name, v, as.ValMin, as.ValMax)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package crux
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLooseBoolCoercion(t *testing.T) {
+	for val, want := range map[string]bool{
+		"true": true, "1": true, "Yes": true, "T": true,
+		"false": false, "0": false, "no": false, " F ": false,
+	} {
+		got, err := parseLooseBool(val)
+		if err != nil {
+			t.Errorf("parseLooseBool(%q): %v", val, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseLooseBool(%q) = %v, want %v", val, got, want)
+		}
+	}
+	for _, val := range []string{"2", "maybe", "truthy", ""} {
+		if _, err := parseLooseBool(val); err == nil {
+			t.Errorf("parseLooseBool(%q) accepted ambiguous value", val)
+		}
+	}
+}
+
+func TestBoolCoercionInMatching(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "device",
+		PatternSchema: []AttrSchema{
+			{Name: "active", ValType: typeBool},
+		},
+		ActionSchema: ActionSchema{Tasks: []string{"monitor"}},
+	}
+	rs := &RuleSet{
+		Class:   "device",
+		SetName: "fleet",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "active", Op: opEQ, Val: true}},
+			RuleActions:  RuleActions{Tasks: []string{"monitor"}},
+		}},
+	}
+	defer setupTestSchema(t, schema, rs)()
+
+	for _, val := range []string{"true", "1", "yes"} {
+		e := Entity{Class: "device", Attrs: map[string]string{"active": val}}
+		if err := verifyEntity(e); err != nil {
+			t.Errorf("verifyEntity(active=%q): %v", val, err)
+		}
+		actionSet, _, err := doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch(active=%q): %v", val, err)
+		}
+		if len(actionSet.Tasks) != 1 {
+			t.Errorf("active=%q did not match", val)
+		}
+	}
+
+	e := Entity{Class: "device", Attrs: map[string]string{"active": "2"}}
+	if err := verifyEntity(e); err == nil {
+		t.Errorf("verifyEntity accepted ambiguous boolean")
+	}
+}
+
+// Numeric inputs beyond the magnitude limit are garbage or precision
+// hazards and must be rejected; sane values pass unchanged.
+func TestMaxNumericMagnitude(t *testing.T) {
+	if _, err := convertEntityAttrVal("1e30", typeFloat, nil); err == nil {
+		t.Fatalf("absurdly large value accepted")
+	}
+	if _, err := convertEntityAttrVal("12345.5", typeFloat, nil); err != nil {
+		t.Fatalf("normal value rejected: %v", err)
+	}
+	as := &AttrSchema{Name: "qty", ValType: typeInt, MaxMagnitude: 1000}
+	if _, err := convertEntityAttrVal("5000", typeInt, as); err == nil {
+		t.Fatalf("value beyond per-attribute magnitude accepted")
+	}
+	if _, err := convertEntityAttrVal("-500", typeInt, as); err != nil {
+		t.Fatalf("value within per-attribute magnitude rejected: %v", err)
+	}
+	prev := SetMaxNumericMagnitude(10)
+	defer SetMaxNumericMagnitude(prev)
+	if _, err := convertEntityAttrVal("11", typeInt, nil); err == nil {
+		t.Fatalf("value beyond configured engine-wide magnitude accepted")
+	}
+}
+
+// Integer attributes must accept the float spelling JSON encoders give
+// whole numbers, and still reject genuinely fractional values.
+func TestLooseIntCoercion(t *testing.T) {
+	as := &AttrSchema{Name: "stock", ValType: typeInt}
+	for _, val := range []string{"42", "42.0"} {
+		if err := verifyType(val, as); err != nil {
+			t.Errorf("verifyType(%q): %v", val, err)
+		}
+		typed, err := convertEntityAttrVal(val, typeInt, as)
+		if err != nil {
+			t.Errorf("convertEntityAttrVal(%q): %v", val, err)
+		} else if typed != 42 {
+			t.Errorf("convertEntityAttrVal(%q) = %v, want 42", val, typed)
+		}
+	}
+	if err := verifyType("42.5", as); err == nil {
+		t.Errorf("verifyType accepted the fractional 42.5")
+	}
+	if _, err := convertEntityAttrVal("42.5", typeInt, as); err == nil {
+		t.Errorf("convertEntityAttrVal accepted the fractional 42.5")
+	}
+
+	// Term values decoded with UseNumber arrive as json.Number and must
+	// compare like their plainly-decoded forms.
+	holds, err := evalTerm(42, opEQ, json.Number("42"), typeInt, nil)
+	if err != nil || !holds {
+		t.Errorf("42 != json.Number(42): %v %v", holds, err)
+	}
+	holds, err = evalTerm(42, opGT, json.Number("41.5"), typeInt, nil)
+	if err != nil || !holds {
+		t.Errorf("42 not > json.Number(41.5): %v %v", holds, err)
+	}
+}
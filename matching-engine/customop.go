@@ -0,0 +1,40 @@
+package crux
+
+import "fmt"
+
+// CustomOp is a realm-specific pattern operator: Eval applies it to the
+// entity's raw attribute value and the term's value, and Validate, when
+// set, vets terms using it at verification time.
+type CustomOp struct {
+	Validate func(term *RulePatternTerm, as *AttrSchema) error
+	Eval     func(entityVal string, termVal any) (bool, error)
+}
+
+// customOps holds the operators each realm has registered, merged with
+// the built-in validOps during verification and evaluation.
+var customOps = map[string]map[string]CustomOp{}
+
+// RegisterRealmOp adds a domain-specific operator for one realm,
+// refusing names that collide with the built-in operators.
+func RegisterRealmOp(realm, name string, op CustomOp) error {
+	if !attrNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid operator name %q", name)
+	}
+	if _, ok := validOps[name]; ok {
+		return fmt.Errorf("operator %q collides with a built-in operator", name)
+	}
+	if op.Eval == nil {
+		return fmt.Errorf("operator %q needs an Eval function", name)
+	}
+	if customOps[realm] == nil {
+		customOps[realm] = map[string]CustomOp{}
+	}
+	customOps[realm][name] = op
+	return nil
+}
+
+// realmOp looks up a realm's custom operator by name.
+func realmOp(realm, name string) (CustomOp, bool) {
+	op, ok := customOps[realm][name]
+	return op, ok
+}
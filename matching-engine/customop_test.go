@@ -0,0 +1,74 @@
+package crux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// A realm-registered operator verifies and evaluates like a built-in,
+// while other realms and built-in names stay unaffected.
+func TestRealmCustomOperator(t *testing.T) {
+	if err := RegisterRealmOp("acme", "eq", CustomOp{Eval: func(string, any) (bool, error) { return true, nil }}); err == nil {
+		t.Fatalf("built-in operator name accepted for a custom operator")
+	}
+
+	err := RegisterRealmOp("acme", "sameprefix", CustomOp{
+		Validate: func(term *RulePatternTerm, as *AttrSchema) error {
+			if _, ok := term.Val.(string); !ok {
+				return fmt.Errorf("attribute %q: sameprefix needs a string value", term.Attr)
+			}
+			return nil
+		},
+		Eval: func(entityVal string, termVal any) (bool, error) {
+			prefix, ok := termVal.(string)
+			if !ok {
+				return false, fmt.Errorf("sameprefix needs a string value")
+			}
+			return strings.HasPrefix(entityVal, prefix), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRealmOp: %v", err)
+	}
+	defer delete(customOps, "acme")
+
+	rs := &RuleSet{
+		Id:      1,
+		Realm:   "acme",
+		Class:   "inventoryitems",
+		SetName: "customopset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: "sameprefix", Val: "text"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet with custom operator: %v", err)
+	}
+	rs.Realm = "other"
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("custom operator leaked into another realm")
+	}
+	rs.Realm = "acme"
+
+	entity := inventoryEntity()
+	entity.Realm = "acme"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("custom operator rule did not fire: %v", actionSet.Tasks)
+	}
+
+	rs.Rules[0].RulePatterns[0].Val = 7
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("custom operator's Validate did not reject a bad term value")
+	}
+}
@@ -0,0 +1,11 @@
+package crux
+
+// typeDate is a calendar date with no time component, for attributes
+// like effective dates where a timestamp invites off-by-a-day bugs.
+// Values compare by date ordering; a full timestamp is not a legal value
+// for a date attribute, nor a bare date for a timestamp attribute, so
+// mixing the two types fails verification rather than comparing oddly.
+const typeDate = "date"
+
+// dateLayout is the wire format for typeDate attribute values.
+const dateLayout = "2006-01-02"
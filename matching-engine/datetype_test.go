@@ -0,0 +1,53 @@
+package crux
+
+import "testing"
+
+// Date attributes parse the date-only layout and compare by calendar
+// ordering; a timestamp value against a date attribute fails at save.
+func TestDateType(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "effectivedate", ValType: typeDate})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "dateset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "effectivedate", Op: opGE, Val: "2024-01-01"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["effectivedate"] = "2024-03-15"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("date GE comparison did not match: %+v", actionSet)
+	}
+
+	entity.Attrs["effectivedate"] = "2023-12-31"
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("earlier date matched a GE term: %+v", actionSet)
+	}
+
+	if err := verifyType("2024-03-15T10:00:00Z", &AttrSchema{Name: "effectivedate", ValType: typeDate}); err == nil {
+		t.Errorf("timestamp accepted as a date value")
+	}
+
+	rs.Rules[0].RulePatterns[0].Val = "2024-01-01T00:00:00Z"
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("timestamp term value against a date attribute accepted at save")
+	}
+}
@@ -0,0 +1,141 @@
+package crux
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Column-name prefixes marking a decision-table column as an action
+// rather than a condition.
+const (
+	dtTaskPrefix = "task:"
+	dtPropPrefix = "property:"
+)
+
+// LoadDecisionTable parses a CSV decision table into a ruleset and
+// verifies it. Rows are rules; columns are conditions or actions.
+//
+// The table opens with directive rows naming the ruleset:
+//
+//	#class,inventoryitems
+//	#setname,discounts
+//
+// then a header row where plain column names are schema attributes
+// (condition columns) and names prefixed task: or property: are action
+// columns. A condition cell may carry an operator ("> 1000", "!=x"); a
+// bare value means equality, and an empty cell places no condition. A
+// task cell fires the task when non-empty; a property cell's text is the
+// value to set.
+func LoadDecisionTable(r io.Reader) (RuleSet, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("malformed decision table: %v", err)
+	}
+	rs := RuleSet{}
+	var header []string
+	for len(rows) > 0 && len(rows[0]) > 0 && strings.HasPrefix(rows[0][0], "#") {
+		if len(rows[0]) != 2 {
+			return RuleSet{}, fmt.Errorf("directive row %v needs exactly one value", rows[0])
+		}
+		switch rows[0][0] {
+		case "#class":
+			rs.Class = rows[0][1]
+		case "#setname":
+			rs.SetName = rows[0][1]
+		default:
+			return RuleSet{}, fmt.Errorf("unknown directive %q", rows[0][0])
+		}
+		rows = rows[1:]
+	}
+	if rs.Class == "" || rs.SetName == "" {
+		return RuleSet{}, fmt.Errorf("decision table must open with #class and #setname directives")
+	}
+	if len(rows) == 0 {
+		return RuleSet{}, fmt.Errorf("decision table has no header row")
+	}
+	header, rows = rows[0], rows[1:]
+	for rowIdx, row := range rows {
+		if len(row) != len(header) {
+			return RuleSet{}, fmt.Errorf("row %d has %d cells, header has %d", rowIdx+1, len(row), len(header))
+		}
+		rule, err := decisionTableRule(header, row)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("row %d: %v", rowIdx+1, err)
+		}
+		rs.Rules = append(rs.Rules, rule)
+	}
+	if err := verifyRuleSet(&rs, false); err != nil {
+		return RuleSet{}, err
+	}
+	return rs, nil
+}
+
+// decisionTableRule builds one rule from a table row.
+func decisionTableRule(header, row []string) (Rule, error) {
+	var rule Rule
+	for col, cell := range row {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			continue
+		}
+		name := strings.TrimSpace(header[col])
+		switch {
+		case strings.HasPrefix(name, dtTaskPrefix):
+			rule.RuleActions.Tasks = append(rule.RuleActions.Tasks, strings.TrimPrefix(name, dtTaskPrefix))
+		case strings.HasPrefix(name, dtPropPrefix):
+			if rule.RuleActions.Properties == nil {
+				rule.RuleActions.Properties = map[string]string{}
+			}
+			rule.RuleActions.Properties[strings.TrimPrefix(name, dtPropPrefix)] = cell
+		default:
+			term, err := decisionTableTerm(name, cell)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.RulePatterns = append(rule.RulePatterns, term)
+		}
+	}
+	return rule, nil
+}
+
+// decisionTableTerm parses a condition cell like ">1000" or "textbook"
+// into a pattern term on the column's attribute.
+func decisionTableTerm(attr, cell string) (RulePatternTerm, error) {
+	op := opEQ
+	for _, prefix := range []struct {
+		text string
+		op   string
+	}{
+		{">=", opGE}, {"<=", opLE}, {"!=", opNE}, {">", opGT}, {"<", opLT}, {"=", opEQ},
+	} {
+		if strings.HasPrefix(cell, prefix.text) {
+			op = prefix.op
+			cell = strings.TrimSpace(strings.TrimPrefix(cell, prefix.text))
+			break
+		}
+	}
+	if cell == "" {
+		return RulePatternTerm{}, fmt.Errorf("condition on %q has an operator but no value", attr)
+	}
+	return RulePatternTerm{Attr: attr, Op: op, Val: decisionTableVal(cell)}, nil
+}
+
+// decisionTableVal types a cell's text the way JSON loading would: ints,
+// floats and bools when they parse, strings otherwise.
+func decisionTableVal(cell string) any {
+	if n, err := strconv.Atoi(cell); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(cell); err == nil {
+		return b
+	}
+	return cell
+}
@@ -0,0 +1,69 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+const discountTable = `#class,inventoryitems
+#setname,tablediscounts
+cat,mrp,task:invitefordiwali,property:shipby
+textbook,>100,x,air
+notebook,,,road
+`
+
+// A decision table must parse into a ruleset that evaluates exactly like
+// its hand-built equivalent.
+func TestLoadDecisionTable(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+
+	fromTable, err := LoadDecisionTable(strings.NewReader(discountTable))
+	if err != nil {
+		t.Fatalf("LoadDecisionTable: %v", err)
+	}
+	handBuilt := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "tablediscounts",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{
+					{Attr: "cat", Op: opEQ, Val: "textbook"},
+					{Attr: "mrp", Op: opGT, Val: 100},
+				},
+				RuleActions: RuleActions{
+					Tasks:      []string{"invitefordiwali"},
+					Properties: map[string]string{"shipby": "air"},
+				},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+				RuleActions:  RuleActions{Properties: map[string]string{"shipby": "road"}},
+			},
+		},
+	}
+
+	for _, entity := range []Entity{
+		inventoryEntity(), // textbook, mrp 50
+		{Class: "inventoryitems", Attrs: map[string]string{"cat": "textbook", "mrp": "150", "received": "2024-05-01T10:00:00Z"}},
+		{Class: "inventoryitems", Attrs: map[string]string{"cat": "notebook", "mrp": "10", "received": "2024-05-01T10:00:00Z"}},
+	} {
+		got, _, err := doMatch(entity, &fromTable, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch(table ruleset): %v", err)
+		}
+		want, _, err := doMatch(entity, handBuilt, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch(hand-built ruleset): %v", err)
+		}
+		if len(got.Tasks) != len(want.Tasks) || got.Properties["shipby"] != want.Properties["shipby"] {
+			t.Errorf("entity %v: table gave %+v, hand-built gave %+v", entity.Attrs, got, want)
+		}
+	}
+
+	// Verification runs as part of the load.
+	bad := strings.Replace(discountTable, "cat,mrp", "vintage,mrp", 1)
+	if _, err := LoadDecisionTable(strings.NewReader(bad)); err == nil {
+		t.Fatalf("decision table with an unknown attribute column loaded")
+	}
+}
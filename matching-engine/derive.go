@@ -0,0 +1,105 @@
+package crux
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Arithmetic operations a derivation may apply.
+const (
+	deriveAdd = "add"
+	deriveMul = "mul"
+)
+
+// DeriveSpec computes an attribute from two other integer attributes,
+// e.g. a line total derived as qty * price.
+type DeriveSpec struct {
+	Op string // deriveAdd or deriveMul
+	A  string // name of the left operand attribute
+	B  string // name of the right operand attribute
+}
+
+// OverflowPolicy selects what happens when derived integer arithmetic
+// overflows int64.
+type OverflowPolicy int
+
+const (
+	// OverflowError rejects the derivation. This is the default: silent
+	// wraparound would feed wrong numbers into decisions.
+	OverflowError OverflowPolicy = iota
+	// OverflowSaturate clamps the result to the int64 range.
+	OverflowSaturate
+	// OverflowPromote computes the exact result as arbitrary-precision
+	// decimal text, letting float comparisons absorb it downstream.
+	OverflowPromote
+)
+
+// verifyDeriveSpec checks a derivation declaration against its schema:
+// the operation must be known and both operands declared int attributes.
+func verifyDeriveSpec(schema *RuleSchema, as *AttrSchema) error {
+	spec := as.Derive
+	if spec.Op != deriveAdd && spec.Op != deriveMul {
+		return fmt.Errorf("attribute %q: unknown derivation op %q", as.Name, spec.Op)
+	}
+	for _, operand := range []string{spec.A, spec.B} {
+		opAttr := getAttrSchema(schema, operand)
+		if opAttr == nil {
+			return fmt.Errorf("attribute %q: derivation operand %q not in schema", as.Name, operand)
+		}
+		if opAttr.ValType != typeInt {
+			return fmt.Errorf("attribute %q: derivation operand %q must be int, not %q", as.Name, operand, opAttr.ValType)
+		}
+	}
+	return nil
+}
+
+// deriveAttrVal computes a derived attribute's value from the entity's
+// attributes, applying the overflow policy when the exact result leaves
+// the int64 range.
+func deriveAttrVal(attrs map[string]string, spec *DeriveSpec, policy OverflowPolicy) (string, error) {
+	a, err := deriveOperand(attrs, spec.A)
+	if err != nil {
+		return "", err
+	}
+	b, err := deriveOperand(attrs, spec.B)
+	if err != nil {
+		return "", err
+	}
+	exact := new(big.Int)
+	switch spec.Op {
+	case deriveAdd:
+		exact.Add(big.NewInt(a), big.NewInt(b))
+	case deriveMul:
+		exact.Mul(big.NewInt(a), big.NewInt(b))
+	default:
+		return "", fmt.Errorf("unknown derivation op %q", spec.Op)
+	}
+	if exact.IsInt64() {
+		return strconv.FormatInt(exact.Int64(), 10), nil
+	}
+	switch policy {
+	case OverflowSaturate:
+		if exact.Sign() > 0 {
+			return strconv.FormatInt(math.MaxInt64, 10), nil
+		}
+		return strconv.FormatInt(math.MinInt64, 10), nil
+	case OverflowPromote:
+		return exact.String(), nil
+	}
+	return "", fmt.Errorf("derivation %s(%s,%s) overflows int64", spec.Op, spec.A, spec.B)
+}
+
+// deriveOperand fetches one integer operand from the attribute map.
+func deriveOperand(attrs map[string]string, name string) (int64, error) {
+	val, ok := attrs[name]
+	if !ok {
+		return 0, fmt.Errorf("derivation operand %q missing from entity", name)
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("derivation operand %q: %q is not an integer", name, val)
+	}
+	return n, nil
+}
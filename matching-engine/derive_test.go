@@ -0,0 +1,49 @@
+package crux
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// An overflowing multiplication must error by default, clamp under
+// saturate, and produce the exact decimal under promote.
+func TestDeriveOverflowPolicies(t *testing.T) {
+	spec := &DeriveSpec{Op: deriveMul, A: "qty", B: "price"}
+	attrs := map[string]string{
+		"qty":   strconv.FormatInt(math.MaxInt64/2, 10),
+		"price": "4",
+	}
+
+	if _, err := deriveAttrVal(attrs, spec, OverflowError); err == nil {
+		t.Fatalf("overflowing derivation accepted under the default policy")
+	}
+
+	got, err := deriveAttrVal(attrs, spec, OverflowSaturate)
+	if err != nil {
+		t.Fatalf("saturate: %v", err)
+	}
+	if got != strconv.FormatInt(math.MaxInt64, 10) {
+		t.Fatalf("saturate gave %s, want MaxInt64", got)
+	}
+
+	got, err = deriveAttrVal(attrs, spec, OverflowPromote)
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if got != "18446744073709551612" {
+		t.Fatalf("promote gave %s, want the exact product", got)
+	}
+
+	// A result in range is identical under every policy.
+	attrs["qty"] = "3"
+	for _, policy := range []OverflowPolicy{OverflowError, OverflowSaturate, OverflowPromote} {
+		got, err := deriveAttrVal(attrs, spec, policy)
+		if err != nil {
+			t.Fatalf("policy %d: %v", policy, err)
+		}
+		if got != "12" {
+			t.Fatalf("policy %d: got %s, want 12", policy, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package crux
+
+import "fmt"
+
+// RuleIssue is one problem found by a dry-run validation, tied to the
+// rule it occurred in. RuleIdx is -1 for ruleset-level problems.
+type RuleIssue struct {
+	RuleIdx int    `json:"ruleidx"`
+	Msg     string `json:"msg"`
+}
+
+// RuleSetValidation is the structured outcome of validating a ruleset
+// without saving it: per-rule issues, dangling references and call
+// cycles, and an overall verdict.
+type RuleSetValidation struct {
+	OK       bool          `json:"ok"`
+	Issues   []RuleIssue   `json:"issues,omitempty"`
+	Dangling []DanglingRef `json:"dangling,omitempty"`
+}
+
+// ValidateRuleSet dry-runs every check a save would perform — schema
+// verification rule by rule, cross-rule constraints, referential checks
+// and cycle detection — and reports all findings instead of stopping at
+// the first, so an authoring UI can show the full list. It never
+// touches the loaded rulesets.
+func ValidateRuleSet(rs *RuleSet, isWF bool) RuleSetValidation {
+	var v RuleSetValidation
+	schema := schemaFor(rs)
+	if schema == nil {
+		v.Issues = append(v.Issues, RuleIssue{RuleIdx: -1,
+			Msg: fmt.Sprintf("no schema found for class %q", rs.Class)})
+		return v
+	}
+	for i := range rs.Rules {
+		if err := verifyRule(rs, schema, i, isWF); err != nil {
+			v.Issues = append(v.Issues, RuleIssue{RuleIdx: i, Msg: err.Error()})
+		}
+	}
+	if err := verifyPropConstraints(rs, schema); err != nil {
+		v.Issues = append(v.Issues, RuleIssue{RuleIdx: -1, Msg: err.Error()})
+	}
+	v.Dangling = DoReferentialChecks([]*RuleSet{rs})
+	if cycle := DetectCallCycle([]*RuleSet{rs}); cycle != nil {
+		v.Issues = append(v.Issues, RuleIssue{RuleIdx: -1,
+			Msg: fmt.Sprintf("THENCALL/ELSECALL cycle through rulesets %v", cycle)})
+	}
+	v.OK = len(v.Issues) == 0 && len(v.Dangling) == 0
+	return v
+}
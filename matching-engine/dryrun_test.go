@@ -0,0 +1,44 @@
+package crux
+
+import "testing"
+
+// A dry-run validation reports every problem — per-rule schema issues
+// and dangling calls — without touching the loaded rulesets.
+func TestValidateRuleSetDryRun(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "draftset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "nosuchattr", Op: opEQ, Val: "x"}},
+				RuleActions:  RuleActions{ThenCall: "nosuchset"},
+			},
+		},
+	}
+	v := ValidateRuleSet(rs, false)
+	if v.OK {
+		t.Fatalf("validation passed a broken ruleset: %+v", v)
+	}
+	if len(v.Issues) != 1 || v.Issues[0].RuleIdx != 1 {
+		t.Errorf("unexpected issues: %+v", v.Issues)
+	}
+	if len(v.Dangling) != 1 || v.Dangling[0].Target != "nosuchset" {
+		t.Errorf("unexpected dangling refs: %+v", v.Dangling)
+	}
+	if _, loaded := ruleSets["draftset"]; loaded {
+		t.Errorf("dry run mutated the loaded rulesets")
+	}
+
+	rs.Rules = rs.Rules[:1]
+	if v := ValidateRuleSet(rs, false); !v.OK {
+		t.Errorf("validation failed a clean ruleset: %+v", v)
+	}
+}
@@ -0,0 +1,28 @@
+package crux
+
+// EmptyAttrPolicy selects whether an attribute carrying the empty string
+// counts as present-with-a-value or as missing during evaluation.
+type EmptyAttrPolicy int
+
+const (
+	// EmptyAsValue treats "" as a real value: an EQ term against "" can
+	// hold. This is the default, preserving the distinction between an
+	// attribute a producer set empty and one it never sent.
+	EmptyAsValue EmptyAttrPolicy = iota
+	// EmptyAsMissing treats "" like an absent attribute: any term on it
+	// fails the pattern, exactly as if the entity never carried it.
+	EmptyAsMissing
+)
+
+// emptyAttrPolicy is the engine-wide policy for empty-string attributes.
+// It applies to the raw value before canonicalization, so a canonical
+// table that maps a value to "" still yields a present, empty value.
+var emptyAttrPolicy = EmptyAsValue
+
+// SetEmptyAttrPolicy changes how evaluation treats empty-string
+// attribute values, returning the previous policy.
+func SetEmptyAttrPolicy(p EmptyAttrPolicy) EmptyAttrPolicy {
+	prev := emptyAttrPolicy
+	emptyAttrPolicy = p
+	return prev
+}
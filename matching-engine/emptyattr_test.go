@@ -0,0 +1,43 @@
+package crux
+
+import "testing"
+
+// An empty-string attribute matches an EQ-"" term under EmptyAsValue and
+// behaves like an absent attribute under EmptyAsMissing.
+func TestEmptyAttrPolicies(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "supplier", ValType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "emptyset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "supplier", Op: opEQ, Val: ""}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	entity := inventoryEntity()
+	entity.Attrs["supplier"] = ""
+
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch under EmptyAsValue: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("EQ-empty term did not hold under EmptyAsValue: %v", actionSet.Tasks)
+	}
+
+	prev := SetEmptyAttrPolicy(EmptyAsMissing)
+	defer SetEmptyAttrPolicy(prev)
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch under EmptyAsMissing: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Fatalf("empty attribute matched despite EmptyAsMissing: %v", actionSet.Tasks)
+	}
+}
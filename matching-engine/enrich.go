@@ -0,0 +1,63 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultEnrichTimeout bounds a single enrichment lookup.
+const defaultEnrichTimeout = time.Second
+
+// EnrichmentFunc computes extra output properties from an external
+// source, given the entity being matched and the actions collected so
+// far. It must honour ctx, which carries the enrichment timeout.
+type EnrichmentFunc func(ctx context.Context, entity Entity, actionSet ActionSet) (map[string]string, error)
+
+// enrichmentFuncs holds the lookup functions rules may reference by name
+// in their action block.
+var enrichmentFuncs = map[string]EnrichmentFunc{}
+
+// RegisterEnrichmentFunc registers a lookup function under a name that
+// rule actions can reference through their Enrich field. Registration
+// happens at startup, before any ruleset referencing the name is saved.
+func RegisterEnrichmentFunc(name string, fn EnrichmentFunc) {
+	enrichmentFuncs[name] = fn
+}
+
+// callEnrichment runs the named lookup bounded both by the per-lookup
+// timeout and by the evaluation's own context, and returns the
+// properties it produced.
+func callEnrichment(parent context.Context, name string, entity Entity, actionSet ActionSet, timeout time.Duration) (map[string]string, error) {
+	fn, ok := enrichmentFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("enrichment function %q not registered", name)
+	}
+	if timeout <= 0 {
+		timeout = defaultEnrichTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	type result struct {
+		props map[string]string
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		props, err := fn(ctx, entity, actionSet)
+		ch <- result{props, err}
+	}()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, fmt.Errorf("enrichment %q: %v", name, res.err)
+		}
+		return res.props, nil
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return nil, fmt.Errorf("evaluation deadline exceeded during enrichment lookup %q", name)
+		}
+		return nil, fmt.Errorf("enrichment %q timed out after %v", name, timeout)
+	}
+}
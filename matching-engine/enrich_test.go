@@ -0,0 +1,101 @@
+package crux
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnrichmentPopulatesProperty(t *testing.T) {
+	RegisterEnrichmentFunc("custtier", func(ctx context.Context, e Entity, as ActionSet) (map[string]string, error) {
+		return map[string]string{"shipby": "premium-" + e.Attrs["cat"]}, nil
+	})
+	defer delete(enrichmentFuncs, "custtier")
+
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Enrich: "custtier"},
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+	actionSet, _, err := doMatch(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if got := actionSet.Properties["shipby"]; got != "premium-textbook" {
+		t.Errorf("shipby = %q, want enriched value", got)
+	}
+}
+
+func TestEnrichmentValidatedAtSave(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RuleActions: RuleActions{Enrich: "nosuchfn"},
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("verifyRuleSet accepted unregistered enrichment function")
+	}
+}
+
+func TestEnrichmentTimeout(t *testing.T) {
+	RegisterEnrichmentFunc("slow", func(ctx context.Context, e Entity, as ActionSet) (map[string]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	defer delete(enrichmentFuncs, "slow")
+
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Enrich: "slow"},
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+
+	cfg := matchConfig{enrichTimeout: 10 * time.Millisecond}
+	if _, _, err := doMatchWithConfig(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{}, cfg); err == nil {
+		t.Errorf("expected timeout error from slow enrichment")
+	}
+}
+
+func TestEvaluationDeadlineAttributedToLookup(t *testing.T) {
+	RegisterEnrichmentFunc("glacial", func(ctx context.Context, e Entity, as ActionSet) (map[string]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	defer delete(enrichmentFuncs, "glacial")
+
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Enrich: "glacial"},
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := doMatchContext(ctx, inventoryEntity(), rs, ActionSet{}, map[string]struct{}{}, matchConfig{})
+	if err == nil {
+		t.Fatalf("expected deadline error")
+	}
+	if !strings.Contains(err.Error(), "enrichment lookup") {
+		t.Errorf("error %q not attributed to the lookup phase", err)
+	}
+}
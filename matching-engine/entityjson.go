@@ -0,0 +1,84 @@
+package crux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DupKeyPolicy selects what happens when an entity payload's attrs
+// object carries the same key twice — something encoding/json silently
+// resolves as keep-last, which can hide corrupted or tampered input.
+type DupKeyPolicy int
+
+const (
+	// DupKeyError rejects the payload. This is the default: a duplicate
+	// key is almost always a producer bug.
+	DupKeyError DupKeyPolicy = iota
+	// DupKeyKeepFirst keeps the first value of a duplicated key.
+	DupKeyKeepFirst
+	// DupKeyKeepLast keeps the last value, matching encoding/json.
+	DupKeyKeepLast
+)
+
+// LoadEntityJSON decodes an entity from its JSON form, applying the
+// duplicate-key policy to its attrs object rather than inheriting the
+// decoder's silent keep-last behavior.
+func LoadEntityJSON(data []byte, policy DupKeyPolicy) (Entity, error) {
+	var wire struct {
+		Realm string          `json:"realm"`
+		App   string          `json:"app"`
+		Class string          `json:"class"`
+		Attrs json.RawMessage `json:"attrs"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return Entity{}, fmt.Errorf("malformed entity JSON: %v", err)
+	}
+	e := Entity{Realm: wire.Realm, App: wire.App, Class: wire.Class, Attrs: map[string]string{}}
+	if len(wire.Attrs) == 0 {
+		return e, nil
+	}
+	attrs, err := decodeAttrsObject(wire.Attrs, policy)
+	if err != nil {
+		return Entity{}, err
+	}
+	e.Attrs = attrs
+	return e, nil
+}
+
+// decodeAttrsObject walks the attrs object token by token so duplicate
+// keys are visible, unlike a plain Unmarshal into a map.
+func decodeAttrsObject(data []byte, policy DupKeyPolicy) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("malformed attrs: %v", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("attrs must be a JSON object")
+	}
+	attrs := map[string]string{}
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("malformed attrs: %v", err)
+		}
+		key := keyTok.(string)
+		var val string
+		if err := dec.Decode(&val); err != nil {
+			return nil, fmt.Errorf("attribute %q: value must be a string", key)
+		}
+		if _, dup := attrs[key]; dup {
+			switch policy {
+			case DupKeyKeepFirst:
+				continue
+			case DupKeyKeepLast:
+				// fall through to overwrite
+			default:
+				return nil, attrError(key, "attribute %q appears more than once in the entity payload", key)
+			}
+		}
+		attrs[key] = val
+	}
+	return attrs, nil
+}
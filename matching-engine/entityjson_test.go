@@ -0,0 +1,35 @@
+package crux
+
+import "testing"
+
+// A duplicate attrs key must error by default, and resolve per policy
+// when the caller explicitly opts in to keep-first or keep-last.
+func TestLoadEntityJSONDuplicateKeys(t *testing.T) {
+	payload := []byte(`{
+		"realm": "acme", "class": "inventoryitems",
+		"attrs": {"cat": "textbook", "mrp": "50", "cat": "notebook"}
+	}`)
+
+	if _, err := LoadEntityJSON(payload, DupKeyError); err == nil {
+		t.Fatalf("duplicate key accepted under the default policy")
+	}
+
+	e, err := LoadEntityJSON(payload, DupKeyKeepFirst)
+	if err != nil {
+		t.Fatalf("keep-first: %v", err)
+	}
+	if e.Attrs["cat"] != "textbook" {
+		t.Fatalf("keep-first resolved cat to %q, want %q", e.Attrs["cat"], "textbook")
+	}
+
+	e, err = LoadEntityJSON(payload, DupKeyKeepLast)
+	if err != nil {
+		t.Fatalf("keep-last: %v", err)
+	}
+	if e.Attrs["cat"] != "notebook" {
+		t.Fatalf("keep-last resolved cat to %q, want %q", e.Attrs["cat"], "notebook")
+	}
+	if e.Realm != "acme" || e.Attrs["mrp"] != "50" {
+		t.Fatalf("entity fields lost in decoding: %+v", e)
+	}
+}
@@ -0,0 +1,91 @@
+package crux
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// enumOrdinalsEnabled selects whether enum terms compare by compiled
+// integer ordinals instead of strings. On by default; the semantics are
+// identical, only the representation differs.
+var enumOrdinalsEnabled = true
+
+// SetEnumOrdinalCoercion turns ordinal coercion of enum comparisons on
+// or off and returns the previous setting.
+func SetEnumOrdinalCoercion(enabled bool) bool {
+	prev := enumOrdinalsEnabled
+	enumOrdinalsEnabled = enabled
+	return prev
+}
+
+// enumTables caches the compiled value-to-ordinal table per attribute
+// schema, built on first use.
+var enumTables = struct {
+	mu     sync.Mutex
+	tables map[*AttrSchema]map[string]int
+}{tables: map[*AttrSchema]map[string]int{}}
+
+// enumTable compiles (and caches) an enum attribute's ordinal table.
+// Ordinals follow the declared ordering when the enum has one, else the
+// values sorted lexically — stable either way for a given schema, so an
+// ordinal never changes meaning between evaluations.
+func enumTable(as *AttrSchema) map[string]int {
+	enumTables.mu.Lock()
+	defer enumTables.mu.Unlock()
+	if tbl, ok := enumTables.tables[as]; ok {
+		return tbl
+	}
+	tbl := make(map[string]int, len(as.Vals))
+	if len(as.OrderedVals) > 0 {
+		for i, v := range as.OrderedVals {
+			tbl[v] = i
+		}
+	} else {
+		names := make([]string, 0, len(as.Vals))
+		for v := range as.Vals {
+			names = append(names, v)
+		}
+		sort.Strings(names)
+		for i, v := range names {
+			tbl[v] = i
+		}
+	}
+	enumTables.tables[as] = tbl
+	return tbl
+}
+
+// enumOrdinalOf resolves a value's rank in the enum ordering: one table
+// lookup when coercion is on, a scan of the declared order otherwise.
+func enumOrdinalOf(as *AttrSchema, val string) (int, bool) {
+	if enumOrdinalsEnabled {
+		ord, ok := enumTable(as)[val]
+		return ord, ok
+	}
+	return enumOrdinal(as, val)
+}
+
+// evalEnumIdentityTerm compares an enum entity value with an EQ/NE term
+// on ordinals. The term value not being a declared enum value simply
+// fails to equal anything, matching the string path's behavior.
+func evalEnumIdentityTerm(entityVal string, term *RulePatternTerm, as *AttrSchema) (bool, error) {
+	termStr, ok := term.Val.(string)
+	if !ok {
+		return false, fmt.Errorf("attribute %q: type mismatch comparing string values", term.Attr)
+	}
+	tbl := enumTable(as)
+	evOrd, evIn := tbl[entityVal]
+	tvOrd, tvIn := tbl[termStr]
+	var eq bool
+	if evIn && tvIn {
+		eq = evOrd == tvOrd
+	} else {
+		// An undeclared value keeps string identity, matching the
+		// string path exactly.
+		eq = entityVal == termStr
+	}
+	if term.Op == opNE {
+		return !eq, nil
+	}
+	return eq, nil
+}
@@ -0,0 +1,104 @@
+package crux
+
+import (
+	"fmt"
+	"testing"
+)
+
+// gradeSchema builds an inventory schema with a large ordered enum, for
+// the ordinal coercion test and benchmark.
+func gradeSchema(n int) (*RuleSchema, []string) {
+	vals := map[string]struct{}{}
+	ordered := make([]string, n)
+	for i := 0; i < n; i++ {
+		v := fmt.Sprintf("g%03d", i)
+		vals[v] = struct{}{}
+		ordered[i] = v
+	}
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{
+		Name:        "grade",
+		ValType:     typeEnum,
+		Vals:        vals,
+		OrderedVals: ordered,
+	})
+	return schema, ordered
+}
+
+// Ordinal coercion produces the same results as the string path for EQ,
+// NE and ordered comparisons, declared and undeclared values included.
+func TestEnumOrdinalCoercion(t *testing.T) {
+	schema, ordered := gradeSchema(20)
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "ordinalset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{
+				{Attr: "cat", Op: opEQ, Val: "textbook"},
+				{Attr: "cat", Op: opNE, Val: "notebook"},
+				{Attr: "grade", Op: opGE, Val: ordered[10]},
+			},
+			RuleActions: RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	entities := []Entity{inventoryEntity(), inventoryEntity(), inventoryEntity()}
+	entities[0].Attrs["grade"] = ordered[15]
+	entities[1].Attrs["grade"] = ordered[5]
+	entities[2].Attrs["cat"] = "magazine" // undeclared value via passthrough
+	entities[2].Attrs["grade"] = ordered[15]
+
+	for i, entity := range entities {
+		var results []int
+		for _, enabled := range []bool{true, false} {
+			prev := SetEnumOrdinalCoercion(enabled)
+			actionSet, _, err := doMatch(entity, rs,
+				ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+			SetEnumOrdinalCoercion(prev)
+			if err != nil {
+				t.Fatalf("entity %d coercion=%v: %v", i, enabled, err)
+			}
+			results = append(results, len(actionSet.Tasks))
+		}
+		if results[0] != results[1] {
+			t.Errorf("entity %d: coercion changed the result: %v", i, results)
+		}
+	}
+}
+
+// benchEnumMatch drives the ordered-enum rule from the coercion test.
+func benchEnumMatch(b *testing.B, enabled bool) {
+	schema, ordered := gradeSchema(100)
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "ordinalbench",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "grade", Op: opGE, Val: ordered[50]}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	savedSchemas, savedSets := ruleSchemas, ruleSets
+	ruleSchemas = []*RuleSchema{schema}
+	ruleSets = map[string]*RuleSet{rs.SetName: rs}
+	invalidateSchemaIndex()
+	defer func() { ruleSchemas, ruleSets = savedSchemas, savedSets; invalidateSchemaIndex() }()
+
+	entity := inventoryEntity()
+	entity.Attrs["grade"] = ordered[99]
+	prev := SetEnumOrdinalCoercion(enabled)
+	defer SetEnumOrdinalCoercion(prev)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := doMatch(entity, rs,
+			ActionSet{Properties: map[string]string{}}, map[string]struct{}{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEnumMatchOrdinals(b *testing.B) { benchEnumMatch(b, true) }
+func BenchmarkEnumMatchStrings(b *testing.B)  { benchEnumMatch(b, false) }
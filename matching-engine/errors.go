@@ -0,0 +1,39 @@
+package crux
+
+import "fmt"
+
+// FieldError is a validation error tied to a specific field of the
+// submitted entity or schema. Path locates the field structurally, e.g.
+// ["attrs", "mrp"] for an entity attribute, so the HTTP layer can emit a
+// JSON pointer into the request body.
+type FieldError struct {
+	Path []string
+	Msg  string
+}
+
+func (e *FieldError) Error() string {
+	return e.Msg
+}
+
+// JSONPointerPath implements wscutils.Pointered.
+func (e *FieldError) JSONPointerPath() []string {
+	return e.Path
+}
+
+// attrError builds a FieldError for an entity attribute.
+func attrError(attr, format string, args ...any) *FieldError {
+	return &FieldError{
+		Path: []string{"attrs", attr},
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+// schemaAttrError builds a FieldError for one attribute of a pattern
+// schema, located by its index in the patternschema array.
+func schemaAttrError(idx int, field, format string, args ...any) *FieldError {
+	path := []string{"patternschema", fmt.Sprint(idx)}
+	if field != "" {
+		path = append(path, field)
+	}
+	return &FieldError{Path: path, Msg: fmt.Sprintf(format, args...)}
+}
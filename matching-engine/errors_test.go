@@ -0,0 +1,37 @@
+package crux
+
+import (
+	"testing"
+
+	"github.com/remiges-tech/crux/wscutils"
+)
+
+func TestFieldErrorCarriesJSONPointer(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	e := inventoryEntity()
+	e.Attrs["mrp"] = "not-a-number"
+
+	err := verifyEntity(e)
+	if err == nil {
+		t.Fatalf("verifyEntity accepted bad mrp")
+	}
+	em := wscutils.NewErrorMessage(err)
+	if em.Pointer != "/attrs/mrp" {
+		t.Errorf("pointer = %q, want /attrs/mrp", em.Pointer)
+	}
+	if em.Field != "mrp" {
+		t.Errorf("field = %q, want mrp", em.Field)
+	}
+}
+
+func TestSchemaErrorCarriesJSONPointer(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema[1].ValType = "decimalish"
+	err := verifyRuleSchema(schema, false)
+	if err == nil {
+		t.Fatalf("verifyRuleSchema accepted bad valtype")
+	}
+	if em := wscutils.NewErrorMessage(err); em.Pointer != "/patternschema/1/valtype" {
+		t.Errorf("pointer = %q, want /patternschema/1/valtype", em.Pointer)
+	}
+}
@@ -0,0 +1,107 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultMaxFanOut bounds the number of ruleset invocations one
+// evaluation may make through THENCALL/ELSECALL chains.
+const defaultMaxFanOut = 100
+
+// matchConfig carries per-evaluation tunables. The zero value selects the
+// engine defaults.
+type matchConfig struct {
+	maxFanOut     int           // cap on ruleset invocations; 0 means defaultMaxFanOut
+	enrichTimeout time.Duration // bound on one enrichment lookup; 0 means defaultEnrichTimeout
+	correlationID string        // caller's correlation/idempotency ID, if any
+	tracePercent  int           // trace sampling rate; 0 disables tracing
+	checkRequired bool          // error when a required task is absent from the result
+}
+
+// evalCtx is the per-evaluation state threaded through the doMatch
+// recursion.
+type evalCtx struct {
+	ctx      context.Context // bounds the whole evaluation, including lookups
+	cfg      matchConfig
+	nInvoked int    // rulesets entered so far in this evaluation
+	depth    int    // current THENCALL/ELSECALL recursion depth
+	maxDepth int    // deepest recursion reached in this evaluation
+	trace    *Trace // non-nil when this evaluation was sampled
+	curSpan  *Span  // span of the invocation currently underway, if exporting
+
+	// matchedRules records every rule that fired, as "setname#ruleidx"
+	// in match order, for decision receipts.
+	matchedRules []string
+
+	explainOn bool          // capture an explain trace; see doMatchExplain
+	explain   []ExplainStep // the trace, in evaluation order
+}
+
+// run wraps one top-level doMatch call, deciding whether to capture a
+// trace and storing it once the evaluation finishes.
+func (ec *evalCtx) run(entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}) (ActionSet, bool, error) {
+	if shouldSampleTrace(ec.cfg.correlationID, ec.cfg.tracePercent) {
+		ec.trace = &Trace{CorrelationID: ec.cfg.correlationID}
+	}
+	if schema := schemaFor(ruleSet); schema != nil {
+		prepared, err := prepareEntity(entity, schema)
+		if err != nil {
+			return ActionSet{}, false, err
+		}
+		entity = prepared
+	}
+	started := time.Now()
+	res, keepGoing, err := ec.doMatch(entity, ruleSet, actionSet, seenRuleSets)
+	observeEvaluation(ruleSet.SetName, len(ec.matchedRules) > 0, time.Since(started), ec.maxDepth)
+	if ec.trace != nil {
+		ec.trace.Invocations = ec.nInvoked
+		storeTrace(ec.trace)
+	}
+	if err == nil && keepGoing && ec.cfg.checkRequired {
+		if missing := missingRequiredTasks(ruleSet.Class, res); len(missing) > 0 {
+			return res, keepGoing, fmt.Errorf("evaluation did not produce required tasks %v", missing)
+		}
+	}
+	return res, keepGoing, err
+}
+
+// missingRequiredTasks lists the tasks the class's action schema marks
+// required that the evaluation's result does not carry.
+func missingRequiredTasks(class string, actionSet ActionSet) []string {
+	schema := getRuleSchema(class)
+	if schema == nil {
+		return nil
+	}
+	var missing []string
+	for _, required := range schema.ActionSchema.RequiredTasks {
+		found := false
+		for _, task := range actionSet.Tasks {
+			if task == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
+
+// context returns the evaluation's context, defaulting to Background.
+func (ec *evalCtx) context() context.Context {
+	if ec.ctx != nil {
+		return ec.ctx
+	}
+	return context.Background()
+}
+
+// fanOutCap returns the effective invocation cap for this evaluation.
+func (ec *evalCtx) fanOutCap() int {
+	if ec.cfg.maxFanOut > 0 {
+		return ec.cfg.maxFanOut
+	}
+	return defaultMaxFanOut
+}
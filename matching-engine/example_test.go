@@ -0,0 +1,29 @@
+package crux
+
+import (
+	"testing"
+)
+
+func TestGenerateExampleEntity(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "qty", ValType: typeInt, ValMin: 5, ValMax: 500},
+		AttrSchema{Name: "instock", ValType: typeBool},
+	)
+	defer setupTestSchema(t, schema, nil)()
+
+	e, err := GenerateExampleEntity("inventoryitems")
+	if err != nil {
+		t.Fatalf("GenerateExampleEntity: %v", err)
+	}
+	if err := verifyEntity(e); err != nil {
+		t.Errorf("generated entity fails verifyEntity: %v", err)
+	}
+	if e.Attrs["qty"] != "5" {
+		t.Errorf("qty = %q, want low end of range", e.Attrs["qty"])
+	}
+
+	if _, err := GenerateExampleEntity("nosuchclass"); err == nil {
+		t.Errorf("expected error for unknown class")
+	}
+}
@@ -0,0 +1,58 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExplainStep records what the engine did with one rule, in evaluation
+// order across THENCALL/ELSECALL chains. Exactly one of Matched and
+// Skipped is meaningful: Skipped marks a rule the unknown-attribute
+// policy passed over without evaluating.
+type ExplainStep struct {
+	SetName    string            `json:"setname"`
+	RuleIdx    int               `json:"ruleidx"`
+	Matched    bool              `json:"matched"`
+	Skipped    bool              `json:"skipped,omitempty"`
+	Tasks      []string          `json:"tasks,omitempty"`      // tasks the rule contributed
+	Properties map[string]string `json:"properties,omitempty"` // properties the rule contributed
+	Called     string            `json:"called,omitempty"`     // ruleset entered via THENCALL/ELSECALL
+	Returned   bool              `json:"returned,omitempty"`   // the rule's RETURN ended the ruleset
+	Exited     bool              `json:"exited,omitempty"`     // the rule's EXIT ended the evaluation
+}
+
+// MatchExplain evaluates like Match with the explain trace switched on:
+// the second result lists every rule touched, in order, with what it
+// did. The trace costs an allocation per rule, so it stays opt-in
+// rather than part of the normal path.
+func MatchExplain(ctx context.Context, setName string, entity Entity) (ActionSet, []ExplainStep, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return ActionSet{}, nil, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return ActionSet{}, nil, err
+	}
+	ec := &evalCtx{ctx: ctx, explainOn: true}
+	res, _, err := ec.run(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	return res, ec.explain, err
+}
+
+// doMatchExplain is doMatch with the explain trace switched on: the
+// third result lists every rule touched, in order, with what it did.
+// The trace costs an allocation per rule, so it is opt-in rather than
+// part of the normal path.
+func doMatchExplain(entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}) (ActionSet, bool, []ExplainStep, error) {
+	ec := &evalCtx{explainOn: true}
+	res, keepGoing, err := ec.run(entity, ruleSet, actionSet, seenRuleSets)
+	return res, keepGoing, ec.explain, err
+}
+
+// recordExplain appends a step to the explain trace when one is being
+// captured.
+func (ec *evalCtx) recordExplain(step ExplainStep) {
+	if ec.explainOn {
+		ec.explain = append(ec.explain, step)
+	}
+}
@@ -0,0 +1,79 @@
+package crux
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// The explain trace lists every rule in evaluation order, across a
+// THENCALL into a second ruleset, and marks the RETURN that ended it.
+func TestExplainTrace(t *testing.T) {
+	called := &RuleSet{
+		Id:      2,
+		Class:   "inventoryitems",
+		SetName: "explaincalled",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opLT, Val: 100.0}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}, WillReturn: true},
+		}},
+	}
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "explainset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{ThenCall: "explaincalled"},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	ruleSets["explaincalled"] = called
+
+	actionSet, _, steps, err := doMatchExplain(inventoryEntity(), rs,
+		ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatchExplain: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("unexpected result: %+v", actionSet)
+	}
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %+v", steps)
+	}
+	if steps[0].SetName != "explainset" || steps[0].RuleIdx != 0 || steps[0].Matched {
+		t.Errorf("unexpected step 0: %+v", steps[0])
+	}
+	if !steps[1].Matched || steps[1].Called != "explaincalled" {
+		t.Errorf("unexpected step 1: %+v", steps[1])
+	}
+	if steps[2].SetName != "explaincalled" || !steps[2].Matched || !steps[2].Returned {
+		t.Errorf("unexpected step 2: %+v", steps[2])
+	}
+
+	if _, err := json.Marshal(steps); err != nil {
+		t.Errorf("explain trace not JSON-serializable: %v", err)
+	}
+
+	// The exported opt-in form returns the same trace.
+	_, exported, err := MatchExplain(context.Background(), "explainset", inventoryEntity())
+	if err != nil {
+		t.Fatalf("MatchExplain: %v", err)
+	}
+	if len(exported) != 3 {
+		t.Errorf("MatchExplain returned %d steps, want 3", len(exported))
+	}
+
+	// The normal path captures nothing.
+	if _, _, err := doMatch(inventoryEntity(), rs,
+		ActionSet{Properties: map[string]string{}}, map[string]struct{}{}); err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package crux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property output formats declarable in ActionSchema.PropFormats. A
+// format is applied to a property value as a rule sets it, so downstream
+// consumers always see the property in its contracted shape.
+//
+//	rfc3339      parse the value as a timestamp, re-emit as RFC3339 UTC
+//	decimal(n)   parse the value as a number, emit with n fixed decimals
+const (
+	formatRFC3339       = "rfc3339"
+	formatDecimalPrefix = "decimal("
+)
+
+// verifyPropFormat checks a format string at schema-save time so bad
+// formats are rejected before any rule can trip over them.
+func verifyPropFormat(format string) error {
+	if format == formatRFC3339 {
+		return nil
+	}
+	if n, ok := decimalPlaces(format); ok {
+		if n < 0 || n > 20 {
+			return fmt.Errorf("decimal precision %d outside [0,20]", n)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// formatPropertyVal applies a declared output format to a resolved
+// property value.
+func formatPropertyVal(val, format string) (string, error) {
+	if format == formatRFC3339 {
+		t, err := parseLooseTimestamp(val)
+		if err != nil {
+			return "", fmt.Errorf("cannot format %q as rfc3339: %v", val, err)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if n, ok := decimalPlaces(format); ok {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return "", fmt.Errorf("cannot format %q as decimal: %v", val, err)
+		}
+		return strconv.FormatFloat(f, 'f', n, 64), nil
+	}
+	return "", fmt.Errorf("unknown output format %q", format)
+}
+
+// decimalPlaces parses a "decimal(n)" format string.
+func decimalPlaces(format string) (int, bool) {
+	if !strings.HasPrefix(format, formatDecimalPrefix) || !strings.HasSuffix(format, ")") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(format[len(formatDecimalPrefix) : len(format)-1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseLooseTimestamp accepts the handful of timestamp shapes rules tend
+// to carry in property values.
+func parseLooseTimestamp(val string) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+	var lastErr error
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, val)
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
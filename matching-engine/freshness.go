@@ -0,0 +1,56 @@
+package crux
+
+import (
+	"fmt"
+	"time"
+)
+
+// StaleAttrPolicy selects what happens when a pattern term references an
+// attribute whose captured value has outlived the schema's freshness
+// TTL.
+type StaleAttrPolicy int
+
+const (
+	// StaleAsMissing treats the stale attribute as absent, so the term
+	// fails and the rule simply does not fire. This is the engine
+	// default.
+	StaleAsMissing StaleAttrPolicy = iota
+	// StaleError aborts the evaluation, for realms where deciding on
+	// stale data is worse than not deciding at all.
+	StaleError
+)
+
+// staleAttrPolicy is the engine-wide policy for stale attributes.
+var staleAttrPolicy = StaleAsMissing
+
+// SetStaleAttrPolicy changes how the evaluator handles attributes older
+// than their freshness TTL, returning the previous policy.
+func SetStaleAttrPolicy(p StaleAttrPolicy) StaleAttrPolicy {
+	prev := staleAttrPolicy
+	staleAttrPolicy = p
+	return prev
+}
+
+// attrIsStale reports whether the attribute's captured-at timestamp, as
+// recorded in the entity's provenance, is older than the schema's MaxAge
+// at asOf. An attribute with no recorded capture time counts as stale:
+// a freshness SLA cannot be met by data of unknown age.
+func attrIsStale(entity Entity, attr string, as *AttrSchema, asOf time.Time) bool {
+	if as == nil || as.MaxAge <= 0 {
+		return false
+	}
+	prov, ok := entity.Provenance[attr]
+	if !ok || prov.CapturedAt.IsZero() {
+		return true
+	}
+	return asOf.Sub(prov.CapturedAt) > as.MaxAge
+}
+
+// applyStaleAttrPolicy resolves a stale attribute per the engine policy:
+// failTerm to treat it as a plain non-match, or an error to abort.
+func applyStaleAttrPolicy(attr string) (failTerm bool, err error) {
+	if staleAttrPolicy == StaleError {
+		return false, fmt.Errorf("attribute %q exceeded its freshness TTL", attr)
+	}
+	return true, nil
+}
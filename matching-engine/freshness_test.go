@@ -0,0 +1,60 @@
+package crux
+
+import (
+	"testing"
+	"time"
+)
+
+// A rule referencing an attribute older than its freshness TTL is
+// skipped under the default policy and aborts under StaleError; a fresh
+// capture matches normally.
+func TestAttrFreshnessTTL(t *testing.T) {
+	schema := inventorySchema()
+	if as := getAttrSchema(schema, "cat"); as != nil {
+		as.MaxAge = time.Hour
+	}
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "freshset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	entity := inventoryEntity()
+	entity.Provenance = map[string]AttrSource{
+		"cat": {Source: "erp", Trust: 1, CapturedAt: time.Now().UTC().Add(-2 * time.Hour)},
+	}
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("rule fired on a stale attribute: %+v", actionSet)
+	}
+
+	prev := SetStaleAttrPolicy(StaleError)
+	defer SetStaleAttrPolicy(prev)
+	if _, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}); err == nil {
+		t.Fatalf("stale attribute did not abort under StaleError")
+	}
+
+	entity.Provenance["cat"] = AttrSource{Source: "erp", Trust: 1, CapturedAt: time.Now().UTC()}
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("rule did not fire on a fresh attribute: %+v", actionSet)
+	}
+
+	badSchema := inventorySchema()
+	badSchema.PatternSchema[0].MaxAge = -time.Minute
+	if err := verifyRuleSchema(badSchema, false); err == nil {
+		t.Fatalf("negative freshness TTL accepted at save")
+	}
+}
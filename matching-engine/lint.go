@@ -0,0 +1,82 @@
+package crux
+
+import "fmt"
+
+// lintWorkflow reports non-fatal issues with a workflow ruleset, to be
+// surfaced as warnings when the workflow is saved. It flags START-step
+// rules whose patterns depend on properties that only rules of later
+// steps produce (such rules can never match a fresh instance), and
+// nextstep targets that no rule in the set handles (the instance would
+// advance into a step and then stall there).
+func lintWorkflow(rs *RuleSet) []string {
+	schema := schemaFor(rs)
+	producedAtStart := map[string]bool{}
+	producedLater := map[string]bool{}
+	for i := range rs.Rules {
+		step, guarded := ruleStep(rs.Rules[i].RulePatterns)
+		for prop := range rs.Rules[i].RuleActions.Properties {
+			if guarded && step != startStep {
+				producedLater[prop] = true
+			} else {
+				producedAtStart[prop] = true
+			}
+		}
+	}
+	var warnings []string
+	for i := range rs.Rules {
+		step, guarded := ruleStep(rs.Rules[i].RulePatterns)
+		if !guarded || step != startStep {
+			continue
+		}
+		for _, term := range rs.Rules[i].RulePatterns {
+			if term.Attr == stepAttr {
+				continue
+			}
+			if schema != nil && getAttrSchema(schema, term.Attr) != nil {
+				continue // entity attribute, present from the start
+			}
+			if producedLater[term.Attr] && !producedAtStart[term.Attr] {
+				warnings = append(warnings, fmt.Sprintf(
+					"rule %d matches on %s=%s but references %q, which only rules of later steps set",
+					i, stepAttr, startStep, term.Attr))
+			}
+		}
+	}
+	warnings = append(warnings, lintUnhandledSteps(rs)...)
+	return warnings
+}
+
+// lintUnhandledSteps flags rules whose nextstep names a step that no
+// rule of the set is guarded on: a transition into such a step leaves
+// the instance with no rule that can ever advance it.
+func lintUnhandledSteps(rs *RuleSet) []string {
+	handled := map[string]bool{}
+	for i := range rs.Rules {
+		if step, guarded := ruleStep(rs.Rules[i].RulePatterns); guarded {
+			handled[step] = true
+		}
+	}
+	var warnings []string
+	for i := range rs.Rules {
+		next, ok := rs.Rules[i].RuleActions.Properties[nextStepProp]
+		if !ok || handled[next] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"rule %d sets %s=%q but no rule handles that step", i, nextStepProp, next))
+	}
+	return warnings
+}
+
+// ruleStep returns the step a rule's pattern is guarded on, if the
+// pattern carries an equality term on the step attribute.
+func ruleStep(pattern []RulePatternTerm) (string, bool) {
+	for _, term := range pattern {
+		if term.Attr == stepAttr && term.Op == opEQ {
+			if s, ok := term.Val.(string); ok {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
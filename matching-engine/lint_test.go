@@ -0,0 +1,97 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+func wfOrderSchema() *RuleSchema {
+	return &RuleSchema{
+		Class: "order",
+		PatternSchema: []AttrSchema{
+			{Name: stepAttr, ValType: typeEnum, Vals: map[string]struct{}{startStep: {}, "approve": {}, "ship": {}}},
+			{Name: stepFailedAttr, ValType: typeBool},
+			{Name: "amount", ValType: typeFloat},
+		},
+		ActionSchema: ActionSchema{
+			Tasks:      []string{"approve", "ship"},
+			Properties: []string{nextStepProp, doneProp, "approver"},
+		},
+	}
+}
+
+func TestLintWorkflowStartDependsOnLateAttr(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "order",
+		SetName: "orderflow",
+		Rules: []Rule{
+			{
+				// START rule depending on "approver", which only the
+				// approve-step rule below sets.
+				RulePatterns: []RulePatternTerm{
+					{Attr: stepAttr, Op: opEQ, Val: startStep},
+					{Attr: "approver", Op: opEQ, Val: "lee"},
+				},
+				RuleActions: RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{nextStepProp: "approve"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "approve"}},
+				RuleActions:  RuleActions{Tasks: []string{"ship"}, Properties: map[string]string{"approver": "lee", nextStepProp: "ship"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "ship"}},
+				RuleActions:  RuleActions{Properties: map[string]string{doneProp: "true"}},
+			},
+		},
+	}
+	defer setupTestSchema(t, wfOrderSchema(), rs)()
+
+	if err := verifyRuleSet(rs, true); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+	warnings := lintWorkflow(rs)
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want exactly one", warnings)
+	}
+	if !strings.Contains(warnings[0], `"approver"`) {
+		t.Errorf("warning %q does not name the offending attribute", warnings[0])
+	}
+
+	// Referencing an entity attribute from START is fine.
+	rs.Rules[0].RulePatterns[1] = RulePatternTerm{Attr: "amount", Op: opGT, Val: 100.0}
+	if warnings := lintWorkflow(rs); len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+// A nextstep naming a step no rule is guarded on is flagged; once a rule
+// handles that step the warning goes away.
+func TestLintWorkflowUnhandledNextStep(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "order",
+		SetName: "stallflow",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: startStep}},
+				RuleActions:  RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{nextStepProp: "approve"}},
+			},
+		},
+	}
+	defer setupTestSchema(t, wfOrderSchema(), rs)()
+
+	warnings := lintWorkflow(rs)
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want exactly one", warnings)
+	}
+	if !strings.Contains(warnings[0], `"approve"`) {
+		t.Errorf("warning %q does not name the unhandled step", warnings[0])
+	}
+
+	rs.Rules = append(rs.Rules, Rule{
+		RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "approve"}},
+		RuleActions:  RuleActions{Properties: map[string]string{doneProp: "true"}},
+	})
+	if warnings := lintWorkflow(rs); len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
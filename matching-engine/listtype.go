@@ -0,0 +1,119 @@
+package crux
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// typeList is a multi-valued attribute. The wire form is a JSON array
+// ("[\"red\",\"blue\"]"); the schema's ElemType declares the scalar type
+// every element must conform to.
+const typeList = "list"
+
+// opCONTAINS tests element membership against a typeList attribute, or
+// substring containment against a typeStr attribute.
+const opCONTAINS = "contains"
+
+// verifyElemType checks a list attribute's declared element type, which
+// must be one of the scalar value types.
+func verifyElemType(as *AttrSchema) error {
+	switch as.ElemType {
+	case typeBool, typeInt, typeFloat, typeStr, typeTS:
+		return nil
+	case "":
+		return fmt.Errorf("attribute %q: list attribute declares no element type", as.Name)
+	}
+	return fmt.Errorf("attribute %q: list elements cannot be of type %q", as.Name, as.ElemType)
+}
+
+// parseListVal decodes a list attribute's wire value and returns the
+// elements in their wire (string) form, each validated against the
+// declared element type.
+func parseListVal(val string, as *AttrSchema) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(val))
+	dec.UseNumber()
+	var raw []any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("attribute %q: %q is not a JSON array", as.Name, val)
+	}
+	elemSchema := &AttrSchema{Name: as.Name, ValType: as.ElemType}
+	elems := make([]string, len(raw))
+	for i, e := range raw {
+		var s string
+		switch v := e.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = "false"
+			if v {
+				s = "true"
+			}
+		default:
+			return nil, fmt.Errorf("attribute %q: list element %d is not a scalar", as.Name, i)
+		}
+		if err := verifyType(s, elemSchema); err != nil {
+			return nil, err
+		}
+		elems[i] = s
+	}
+	return elems, nil
+}
+
+// verifyContainsTerm checks a contains term at save: the attribute must
+// be a list (operand a legal value of its element type) or a string
+// (operand a substring); any other attribute type is an error.
+func verifyContainsTerm(rs *RuleSet, ruleIdx int, term *RulePatternTerm, as *AttrSchema) error {
+	if as != nil && as.ValType == typeStr {
+		if _, ok := term.Val.(string); !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a string value", rs.SetName, ruleIdx, term.Attr)
+		}
+		return nil
+	}
+	if as == nil || as.ValType != typeList {
+		got := "unknown"
+		if as != nil {
+			got = as.ValType
+		}
+		return fmt.Errorf("ruleset %q rule %d: operator %q not valid for %s attribute %q", rs.SetName, ruleIdx, opCONTAINS, got, term.Attr)
+	}
+	elemSchema := &AttrSchema{Name: term.Attr, ValType: as.ElemType}
+	elemTerm := RulePatternTerm{Attr: term.Attr, Op: opEQ, Val: term.Val}
+	return verifyTermVal(rs, ruleIdx, &elemTerm, elemSchema)
+}
+
+// evalContainsTerm applies a contains term: substring containment for a
+// string attribute, element membership for a list attribute.
+func evalContainsTerm(entityVal string, term *RulePatternTerm, as *AttrSchema, coll CollatorFunc) (bool, error) {
+	if as != nil && as.ValType == typeStr {
+		sub, ok := term.Val.(string)
+		if !ok {
+			return false, fmt.Errorf("operator %q needs a string value", opCONTAINS)
+		}
+		return strings.Contains(entityVal, sub), nil
+	}
+	if as == nil || as.ValType != typeList {
+		return false, fmt.Errorf("operator %q needs a list or string attribute", opCONTAINS)
+	}
+	elems, err := parseListVal(entityVal, as)
+	if err != nil {
+		return false, err
+	}
+	elemSchema := &AttrSchema{Name: as.Name, ValType: as.ElemType}
+	for _, elem := range elems {
+		typed, err := convertEntityAttrVal(elem, as.ElemType, elemSchema)
+		if err != nil {
+			return false, err
+		}
+		holds, err := evalTerm(typed, opEQ, term.Val, as.ElemType, coll)
+		if err != nil {
+			return false, err
+		}
+		if holds {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,118 @@
+package crux
+
+import "testing"
+
+// A list attribute carries a JSON array of its element type; contains
+// matches element membership, scalar operators are rejected at save, and
+// element-type violations fail entity verification. The workflow
+// step/stepfailed checks are untouched by the new type.
+func TestListTypeContains(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "tags", ValType: typeList, ElemType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "listset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "tags", Op: opCONTAINS, Val: "fragile"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["tags"] = `["heavy","fragile"]`
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("contains did not match a listed element: %+v", actionSet)
+	}
+
+	entity.Attrs["tags"] = `["heavy"]`
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("contains matched an absent element: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0].Op = opEQ
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("scalar operator on a list attribute accepted at save")
+	}
+	rs.Rules[0].RulePatterns[0].Op = opCONTAINS
+
+	intList := &AttrSchema{Name: "sizes", ValType: typeList, ElemType: typeInt}
+	if err := verifyType(`[1,2,"three"]`, intList); err == nil {
+		t.Fatalf("list with a type-mismatched element passed verification")
+	}
+	if err := verifyType(`[1,2,3]`, intList); err != nil {
+		t.Errorf("well-typed int list rejected: %v", err)
+	}
+
+	badSchema := inventorySchema()
+	badSchema.PatternSchema = append(badSchema.PatternSchema,
+		AttrSchema{Name: "tags", ValType: typeList, ElemType: "list"})
+	if err := verifyRuleSchema(badSchema, false); err == nil {
+		t.Fatalf("nested list element type accepted at save")
+	}
+
+	if err := verifyRuleSchema(wfOrderSchema(), true); err != nil {
+		t.Errorf("workflow schema checks affected by list type: %v", err)
+	}
+}
+
+// Contains on a string attribute is substring matching; on an int
+// attribute it is a verification error.
+func TestContainsSubstring(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "title", ValType: typeStr},
+		AttrSchema{Name: "stock", ValType: typeInt})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "substrset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "title", Op: opCONTAINS, Val: "algebra"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["title"] = "linear algebra done right"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("substring contains did not match: %+v", actionSet)
+	}
+
+	entity.Attrs["title"] = "organic chemistry"
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("contains matched an absent substring: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "stock", Op: opCONTAINS, Val: "4"}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("contains on an int attribute accepted at save")
+	}
+}
@@ -0,0 +1,137 @@
+package crux
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// opAliases maps external operator spellings to the internal operators,
+// so rulesets imported from heterogeneous sources load cleanly. The
+// canonical spellings are always accepted.
+var opAliases = map[string]string{
+	"==":     opEQ,
+	"equals": opEQ,
+	"!=":     opNE,
+	"<>":     opNE,
+	"<":      opLT,
+	"<=":     opLE,
+	">":      opGT,
+	">=":     opGE,
+}
+
+// RegisterOpAlias adds an external spelling for an internal operator.
+func RegisterOpAlias(alias, op string) error {
+	if _, ok := validOps[op]; !ok {
+		return fmt.Errorf("alias %q maps to unknown operator %q", alias, op)
+	}
+	opAliases[alias] = op
+	return nil
+}
+
+// resolveOp maps an operator spelling, canonical or aliased, to the
+// internal operator.
+func resolveOp(op string) (string, error) {
+	if _, ok := validOps[op]; ok {
+		return op, nil
+	}
+	if resolved, ok := opAliases[op]; ok {
+		return resolved, nil
+	}
+	return "", fmt.Errorf("unknown operator %q", op)
+}
+
+// Wire shapes for rulesets loaded from JSON.
+type ruleSetJSON struct {
+	Id      int        `json:"id"`
+	App     string     `json:"app"`
+	Class   string     `json:"class"`
+	SetName string     `json:"setname"`
+	Rules   []ruleJSON `json:"rules"`
+}
+
+type ruleJSON struct {
+	RulePattern []patternTermJSON `json:"rulepattern"`
+	RuleActions ruleActionsJSON   `json:"ruleactions"`
+}
+
+type patternTermJSON struct {
+	Attr            string `json:"attr"`
+	Op              string `json:"op"`
+	Val             any    `json:"val"`
+	Negate          bool   `json:"negate"`
+	CaseInsensitive bool   `json:"caseinsensitive"`
+}
+
+type ruleActionsJSON struct {
+	Tasks      []string          `json:"tasks"`
+	Properties map[string]string `json:"properties"`
+	UseMacro   string            `json:"usemacro"`
+	Enrich     string            `json:"enrich"`
+	ThenCall   string            `json:"thencall"`
+	ElseCall   string            `json:"elsecall"`
+	WillReturn bool              `json:"return"`
+	WillExit   bool              `json:"exit"`
+}
+
+// LoadRuleSetJSON decodes a ruleset from its JSON form, resolving
+// operator aliases and normalizing JSON numbers, so the result is ready
+// for verifyRuleSet.
+func LoadRuleSetJSON(data []byte) (*RuleSet, error) {
+	var wire ruleSetJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, fmt.Errorf("malformed ruleset JSON: %v", err)
+	}
+	rs := &RuleSet{Id: wire.Id, App: wire.App, Class: wire.Class, SetName: wire.SetName}
+	for i, rule := range wire.Rules {
+		var terms []RulePatternTerm
+		for _, term := range rule.RulePattern {
+			op, err := resolveOp(term.Op)
+			if err != nil {
+				return nil, fmt.Errorf("ruleset %q rule %d: %v", wire.SetName, i, err)
+			}
+			terms = append(terms, RulePatternTerm{
+				Attr:            term.Attr,
+				Negate:          term.Negate,
+				CaseInsensitive: term.CaseInsensitive,
+				Op:              op,
+				Val:             normalizeJSONVal(term.Val),
+			})
+		}
+		rs.Rules = append(rs.Rules, Rule{
+			RulePatterns: terms,
+			RuleActions: RuleActions{
+				Tasks:      rule.RuleActions.Tasks,
+				Properties: rule.RuleActions.Properties,
+				UseMacro:   rule.RuleActions.UseMacro,
+				Enrich:     rule.RuleActions.Enrich,
+				ThenCall:   rule.RuleActions.ThenCall,
+				ElseCall:   rule.RuleActions.ElseCall,
+				WillReturn: rule.RuleActions.WillReturn,
+				WillExit:   rule.RuleActions.WillExit,
+			},
+		})
+	}
+	if err := expandActionMacros(rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// normalizeJSONVal converts whole-number JSON floats to ints, since JSON
+// has a single number type but pattern terms against int attributes need
+// int values.
+func normalizeJSONVal(val any) any {
+	switch v := val.(type) {
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case json.Number:
+		// A decoder running with UseNumber hands numbers through as
+		// json.Number; resolve them the same way as plain decoding.
+		if f, err := v.Float64(); err == nil {
+			return normalizeJSONVal(f)
+		}
+	}
+	return val
+}
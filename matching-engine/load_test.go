@@ -0,0 +1,53 @@
+package crux
+
+import "testing"
+
+func TestLoadRuleSetJSONWithAliasedOps(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	data := []byte(`{
+		"class": "inventoryitems",
+		"setname": "imported",
+		"rules": [{
+			"rulepattern": [
+				{"attr": "cat", "op": "equals", "val": "textbook"},
+				{"attr": "mrp", "op": ">=", "val": 50}
+			],
+			"ruleactions": {"tasks": ["invitefordiwali"]}
+		}]
+	}`)
+	rs, err := LoadRuleSetJSON(data)
+	if err != nil {
+		t.Fatalf("LoadRuleSetJSON: %v", err)
+	}
+	ruleSets[rs.SetName] = rs
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+	actionSet, _, err := doMatch(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 || actionSet.Tasks[0] != "invitefordiwali" {
+		t.Errorf("tasks = %v, want the rule to have matched", actionSet.Tasks)
+	}
+}
+
+func TestLoadRuleSetJSONUnknownOp(t *testing.T) {
+	data := []byte(`{"class":"inventoryitems","setname":"bad","rules":[{"rulepattern":[{"attr":"cat","op":"resembles","val":"x"}]}]}`)
+	if _, err := LoadRuleSetJSON(data); err == nil {
+		t.Errorf("LoadRuleSetJSON accepted unknown operator")
+	}
+}
+
+func TestRegisterOpAlias(t *testing.T) {
+	if err := RegisterOpAlias("is", opEQ); err != nil {
+		t.Errorf("RegisterOpAlias: %v", err)
+	}
+	defer delete(opAliases, "is")
+	if op, err := resolveOp("is"); err != nil || op != opEQ {
+		t.Errorf("resolveOp(is) = %q, %v", op, err)
+	}
+	if err := RegisterOpAlias("sorta", "almost"); err == nil {
+		t.Errorf("RegisterOpAlias accepted unknown target operator")
+	}
+}
@@ -0,0 +1,66 @@
+package crux
+
+import "fmt"
+
+// actionMacros holds the named action blocks rules may reference instead
+// of repeating the block inline, keyed by macro name.
+var actionMacros = map[string]*RuleActions{}
+
+// RegisterActionMacro defines a named action block for rules to share.
+// Macros may not themselves reference another macro.
+func RegisterActionMacro(name string, actions RuleActions) error {
+	if !attrNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid macro name %q", name)
+	}
+	if actions.UseMacro != "" {
+		return fmt.Errorf("macro %q may not reference another macro", name)
+	}
+	actionMacros[name] = &actions
+	return nil
+}
+
+// expandActionMacros replaces every UseMacro reference in the ruleset
+// with the macro's action block, merged under the rule's own actions:
+// the macro's tasks are appended, its properties fill in only keys the
+// rule does not set itself, and its remaining fields apply only where
+// the rule leaves them empty. Expansion errors on unknown macros.
+func expandActionMacros(rs *RuleSet) error {
+	for i := range rs.Rules {
+		ra := &rs.Rules[i].RuleActions
+		if ra.UseMacro == "" {
+			continue
+		}
+		macro, ok := actionMacros[ra.UseMacro]
+		if !ok {
+			return fmt.Errorf("ruleset %q rule %d: unknown action macro %q", rs.SetName, i, ra.UseMacro)
+		}
+		expandMacroInto(ra, macro)
+		ra.UseMacro = ""
+	}
+	return nil
+}
+
+// expandMacroInto merges macro's actions into ra, with ra's own settings
+// taking precedence.
+func expandMacroInto(ra *RuleActions, macro *RuleActions) {
+	ra.Tasks = append(ra.Tasks, macro.Tasks...)
+	if len(macro.Properties) > 0 && ra.Properties == nil {
+		ra.Properties = map[string]string{}
+	}
+	for prop, val := range macro.Properties {
+		if _, ok := ra.Properties[prop]; !ok {
+			ra.Properties[prop] = val
+		}
+	}
+	if ra.Enrich == "" {
+		ra.Enrich = macro.Enrich
+	}
+	if ra.ThenCall == "" {
+		ra.ThenCall = macro.ThenCall
+	}
+	if ra.ElseCall == "" {
+		ra.ElseCall = macro.ElseCall
+	}
+	ra.WillReturn = ra.WillReturn || macro.WillReturn
+	ra.WillExit = ra.WillExit || macro.WillExit
+}
@@ -0,0 +1,62 @@
+package crux
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Two rules referencing one macro must expand to identical action blocks,
+// and a reference to an unregistered macro must fail the load.
+func TestActionMacroExpansion(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+	savedMacros := actionMacros
+	actionMacros = map[string]*RuleActions{}
+	defer func() { actionMacros = savedMacros }()
+
+	err := RegisterActionMacro("festive", RuleActions{
+		Tasks:      []string{"invitefordiwali"},
+		Properties: map[string]string{"shipby": "air"},
+	})
+	if err != nil {
+		t.Fatalf("RegisterActionMacro: %v", err)
+	}
+
+	data := []byte(`{
+		"id": 1, "class": "inventoryitems", "setname": "macroset",
+		"rules": [
+			{"rulepattern": [{"attr": "cat", "op": "eq", "val": "textbook"}],
+			 "ruleactions": {"usemacro": "festive"}},
+			{"rulepattern": [{"attr": "cat", "op": "eq", "val": "notebook"}],
+			 "ruleactions": {"usemacro": "festive"}}
+		]
+	}`)
+	rs, err := LoadRuleSetJSON(data)
+	if err != nil {
+		t.Fatalf("LoadRuleSetJSON: %v", err)
+	}
+	if !reflect.DeepEqual(rs.Rules[0].RuleActions, rs.Rules[1].RuleActions) {
+		t.Fatalf("rules sharing a macro expanded differently: %+v vs %+v",
+			rs.Rules[0].RuleActions, rs.Rules[1].RuleActions)
+	}
+	if rs.Rules[0].RuleActions.UseMacro != "" {
+		t.Fatalf("macro reference not cleared after expansion")
+	}
+	if got := rs.Rules[0].RuleActions.Tasks; len(got) != 1 || got[0] != "invitefordiwali" {
+		t.Fatalf("macro tasks not expanded: %v", got)
+	}
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("expanded ruleset failed verification: %v", err)
+	}
+
+	bad := []byte(`{
+		"id": 2, "class": "inventoryitems", "setname": "badmacroset",
+		"rules": [
+			{"rulepattern": [{"attr": "cat", "op": "eq", "val": "textbook"}],
+			 "ruleactions": {"usemacro": "nosuchmacro"}}
+		]
+	}`)
+	if _, err := LoadRuleSetJSON(bad); err == nil {
+		t.Fatalf("load accepted a reference to an unregistered macro")
+	}
+}
@@ -0,0 +1,558 @@
+package crux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// doMatch evaluates entity against ruleSet, accumulating matched actions
+// into actionSet. seenRuleSets guards against a ruleset being entered
+// twice in one evaluation through THENCALL/ELSECALL chains. The bool
+// result is false when a matched rule carried EXIT, in which case the
+// returned ActionSet is empty.
+func doMatch(entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}) (ActionSet, bool, error) {
+	return (&evalCtx{}).run(entity, ruleSet, actionSet, seenRuleSets)
+}
+
+// doMatchWithConfig is doMatch with per-evaluation tunables.
+func doMatchWithConfig(entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}, cfg matchConfig) (ActionSet, bool, error) {
+	return (&evalCtx{cfg: cfg}).run(entity, ruleSet, actionSet, seenRuleSets)
+}
+
+// doMatchContext is doMatch bounded by a caller-supplied context, so a
+// client deadline cancels the whole evaluation, lookups included.
+func doMatchContext(ctx context.Context, entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}, cfg matchConfig) (ActionSet, bool, error) {
+	return (&evalCtx{ctx: ctx, cfg: cfg}).run(entity, ruleSet, actionSet, seenRuleSets)
+}
+
+func (ec *evalCtx) doMatch(entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}) (ActionSet, bool, error) {
+	if _, seen := seenRuleSets[ruleSet.SetName]; seen {
+		return ActionSet{}, false, fmt.Errorf("ruleset %q entered twice; THENCALL/ELSECALL cycle", ruleSet.SetName)
+	}
+	ec.nInvoked++
+	ec.depth++
+	defer func() { ec.depth-- }()
+	if ec.depth > ec.maxDepth {
+		ec.maxDepth = ec.depth
+	}
+	engineMetrics.ruleSetInvocations.Add(1)
+	if ec.nInvoked > ec.fanOutCap() {
+		engineMetrics.fanOutExceeded.Add(1)
+		return ActionSet{}, false, fmt.Errorf("evaluation exceeded fan-out cap of %d ruleset invocations at ruleset %q", ec.fanOutCap(), ruleSet.SetName)
+	}
+	seenRuleSets[ruleSet.SetName] = struct{}{}
+	if ec.trace != nil {
+		ec.trace.RuleSetsEntered = append(ec.trace.RuleSetsEntered, ruleSet.SetName)
+	}
+	parentSpan := ec.curSpan
+	span := ec.startSpan(ruleSet)
+	defer ec.endSpan(span, parentSpan)
+	schema := schemaFor(ruleSet)
+	if schema == nil && unknownClassPolicy == UnknownClassError {
+		return ActionSet{}, false, fmt.Errorf("no schema found for class %q", ruleSet.Class)
+	}
+	for i := range ruleSet.Rules {
+		if err := ec.context().Err(); err != nil {
+			return ActionSet{}, false, fmt.Errorf("evaluation deadline exceeded during rule matching in ruleset %q: %v", ruleSet.SetName, err)
+		}
+		rule := &ruleSet.Rules[i]
+		var matched bool
+		var quality matchQuality
+		if attr, unknown := ruleUnknownAttr(rule, schema); unknown {
+			// Version skew: the rule was compiled against a schema that
+			// still had this attribute. The policy decides what happens.
+			skipRule, err := applyUnknownAttrPolicy(ruleSet.SetName, i, attr)
+			if err != nil {
+				return ActionSet{}, false, err
+			}
+			if skipRule {
+				ec.recordExplain(ExplainStep{SetName: ruleSet.SetName, RuleIdx: i, Skipped: true})
+				continue
+			}
+		} else {
+			var err error
+			matched, quality, err = matchPattern(entity, rule.RulePatterns, actionSet, schema)
+			if err != nil {
+				return ActionSet{}, false, err
+			}
+		}
+		if matched && rule.MinConfidence > 0 && quality.confidence < rule.MinConfidence {
+			matched = false // pattern holds but the inputs are not confident enough
+		}
+		if matched && rule.MinTrust > 0 && quality.trust < rule.MinTrust {
+			matched = false // pattern holds but an attribute's source is not trusted enough
+		}
+		if matched {
+			if span != nil {
+				span.RulesMatched++
+			}
+			ec.matchedRules = append(ec.matchedRules, fmt.Sprintf("%s#%d", ruleSet.SetName, i))
+			var err error
+			actionSet, err = ec.collectActions(entity, actionSet, &rule.RuleActions, schema)
+			if err != nil {
+				return ActionSet{}, false, err
+			}
+			ec.recordExplain(ExplainStep{
+				SetName:    ruleSet.SetName,
+				RuleIdx:    i,
+				Matched:    true,
+				Tasks:      rule.RuleActions.Tasks,
+				Properties: rule.RuleActions.Properties,
+				Called:     rule.RuleActions.ThenCall,
+				Returned:   rule.RuleActions.WillReturn,
+				Exited:     rule.RuleActions.WillExit,
+			})
+			if rule.RuleActions.ThenCall != "" {
+				called, ok := ruleSets[rule.RuleActions.ThenCall]
+				if !ok {
+					return ActionSet{}, false, fmt.Errorf("THENCALL references unknown ruleset %q", rule.RuleActions.ThenCall)
+				}
+				var keepGoing bool
+				actionSet, keepGoing, err = ec.doMatch(entity, called, actionSet, seenRuleSets)
+				if err != nil || !keepGoing {
+					return actionSet, keepGoing, err
+				}
+			}
+			if rule.RuleActions.WillReturn {
+				return actionSet, true, nil
+			}
+			if rule.RuleActions.WillExit {
+				return ActionSet{}, false, nil
+			}
+		} else if rule.RuleActions.ElseCall != "" {
+			ec.recordExplain(ExplainStep{SetName: ruleSet.SetName, RuleIdx: i, Called: rule.RuleActions.ElseCall})
+			called, ok := ruleSets[rule.RuleActions.ElseCall]
+			if !ok {
+				return ActionSet{}, false, fmt.Errorf("ELSECALL references unknown ruleset %q", rule.RuleActions.ElseCall)
+			}
+			var keepGoing bool
+			var err error
+			actionSet, keepGoing, err = ec.doMatch(entity, called, actionSet, seenRuleSets)
+			if err != nil || !keepGoing {
+				return actionSet, keepGoing, err
+			}
+		} else {
+			ec.recordExplain(ExplainStep{SetName: ruleSet.SetName, RuleIdx: i})
+		}
+	}
+	return actionSet, true, nil
+}
+
+// matchQuality aggregates the confidence and source trust of the entity
+// attributes a matched pattern referenced (the minimum across terms;
+// attributes without a declared confidence or provenance count as 1).
+type matchQuality struct {
+	confidence float64
+	trust      float64
+}
+
+// matchPattern reports whether every term of the pattern holds for the
+// entity, along with the quality of the referenced inputs. A term may
+// also test a task or property accumulated in the actionSet so far; a
+// term whose attribute is found in neither fails the pattern without
+// error.
+func matchPattern(entity Entity, pattern []RulePatternTerm, actionSet ActionSet, schema *RuleSchema) (bool, matchQuality, error) {
+	quality := matchQuality{confidence: 1, trust: 1}
+	coll := collatorForRealm(entity.Realm)
+	// Terms AND together, so they may run cheapest-first; a failing
+	// identity check then short-circuits past the expensive terms.
+	order := patternOrder(pattern, schema)
+	for n := range pattern {
+		i := n
+		if order != nil {
+			i = order[n]
+		}
+		term := &pattern[i]
+		if schema == nil && !schemalessOpAllowed(term.Op) {
+			return false, matchQuality{}, fmt.Errorf("attribute %q: operator %q not allowed in schema-less mode", term.Attr, term.Op)
+		}
+		if as := getAttrSchema(schema, term.Attr); as != nil && as.Aggregate != nil {
+			holds, err := evalAggregateTerm(entity, term, as)
+			if err != nil {
+				return false, matchQuality{}, err
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if as := getAttrSchema(schema, term.Attr); as != nil && as.Child != nil {
+			holds, err := evalChildTerm(entity, term, as)
+			if err != nil {
+				return false, matchQuality{}, err
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		entityVal, ok := getEntityAttrVal(entity, actionSet, term.Attr)
+		if !ok {
+			return false, matchQuality{}, nil
+		}
+		if conf, ok := entity.Confidence[term.Attr]; ok && conf < quality.confidence {
+			quality.confidence = conf
+		}
+		if prov, ok := entity.Provenance[term.Attr]; ok && prov.Trust < quality.trust {
+			quality.trust = prov.Trust
+		}
+		as := getAttrSchema(schema, term.Attr)
+		if attrIsStale(entity, term.Attr, as, time.Now().UTC()) {
+			failTerm, err := applyStaleAttrPolicy(term.Attr)
+			if err != nil {
+				return false, matchQuality{}, err
+			}
+			if failTerm {
+				return false, matchQuality{}, nil
+			}
+		}
+		valType := typeStr
+		if as != nil {
+			valType = as.ValType
+		}
+		entityVal = canonicalizeVal(entityVal, as)
+		if term.CaseInsensitive && term.Op != opMATCH {
+			// opMATCH is excluded at save; never fold a regex pattern.
+			entityVal = strings.ToLower(entityVal)
+			term = foldedTerm(term)
+		}
+		if term.Op == opIN || term.Op == opNOTIN {
+			holds, err := evalSetTerm(entityVal, term, as, coll)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if term.Op == opMATCH {
+			holds, err := evalRegexTerm(entityVal, term)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if term.Op == opCONTAINS {
+			holds, err := evalContainsTerm(entityVal, term, as, coll)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if term.Op == opBETWEEN {
+			holds, err := evalBetweenTerm(entityVal, term, as, coll)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if op, custom := realmOp(entity.Realm, term.Op); custom {
+			holds, err := op.Eval(entityVal, term.Val)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if as != nil && as.ValType == typeEnum && enumOrdinalsEnabled && coll == nil &&
+			(term.Op == opEQ || term.Op == opNE) {
+			holds, err := evalEnumIdentityTerm(entityVal, term, as)
+			if err != nil {
+				return false, matchQuality{}, err
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		if as != nil && as.ValType == typeEnum && len(as.OrderedVals) > 0 && isOrderingOp(term.Op) {
+			holds, err := evalOrderedEnumTerm(entityVal, term, as)
+			if err != nil {
+				return false, matchQuality{}, err
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		var typedVal any
+		var err error
+		if term.CaseInsensitive {
+			// The typed cache holds the unfolded value; convert the
+			// folded string form instead of consulting it.
+			typedVal, err = convertEntityAttrVal(entityVal, valType, as)
+		} else {
+			typedVal, err = typedEntityAttrVal(entity, term.Attr, entityVal, valType, as)
+		}
+		if err != nil {
+			return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+		}
+		if as != nil && as.ValType == typeTS && as.TSGranularity != "" {
+			holds, err := evalGranularTSTerm(typedVal, term, as)
+			if err != nil {
+				return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+			}
+			if holds == term.Negate {
+				return false, matchQuality{}, nil
+			}
+			continue
+		}
+		holds, err := evalTerm(typedVal, term.Op, term.Val, valType, coll)
+		if err != nil {
+			return false, matchQuality{}, fmt.Errorf("attribute %q: %v", term.Attr, err)
+		}
+		if holds == term.Negate {
+			return false, matchQuality{}, nil
+		}
+	}
+	return true, quality, nil
+}
+
+// propFormatFor looks up a property's declared output format, tolerating
+// a nil schema in schema-less passthrough mode.
+func propFormatFor(schema *RuleSchema, prop string) (string, bool) {
+	if schema == nil {
+		return "", false
+	}
+	format, ok := schema.ActionSchema.PropFormats[prop]
+	return format, ok
+}
+
+// isOrderingOp reports whether an operator compares by order rather than
+// identity.
+func isOrderingOp(op string) bool {
+	return op == opLT || op == opLE || op == opGT || op == opGE
+}
+
+// evalOrderedEnumTerm compares an ordered enum's values by their rank in
+// the schema's declared ordering.
+func evalOrderedEnumTerm(entityVal string, term *RulePatternTerm, as *AttrSchema) (bool, error) {
+	termStr, ok := term.Val.(string)
+	if !ok {
+		return false, fmt.Errorf("attribute %q: term value is not an enum value", term.Attr)
+	}
+	evOrd, ok := enumOrdinalOf(as, entityVal)
+	if !ok {
+		return false, fmt.Errorf("attribute %q: %q has no rank in the enum ordering", term.Attr, entityVal)
+	}
+	tvOrd, ok := enumOrdinalOf(as, termStr)
+	if !ok {
+		return false, fmt.Errorf("attribute %q: %q has no rank in the enum ordering", term.Attr, termStr)
+	}
+	return compareOrdered(float64(evOrd), float64(tvOrd), term.Op)
+}
+
+// getEntityAttrVal fetches the value a pattern term tests: the entity's
+// attribute if present, else a property set earlier in the evaluation,
+// else a task (valued "true") emitted earlier.
+func getEntityAttrVal(entity Entity, actionSet ActionSet, attr string) (string, bool) {
+	if val, ok := entity.Attrs[attr]; ok {
+		if val == "" && emptyAttrPolicy == EmptyAsMissing {
+			return "", false
+		}
+		return val, true
+	}
+	if val, ok := actionSet.Properties[attr]; ok {
+		if val == "" && emptyAttrPolicy == EmptyAsMissing {
+			return "", false
+		}
+		return val, true
+	}
+	for _, task := range actionSet.Tasks {
+		if task == attr {
+			return "true", true
+		}
+	}
+	return "", false
+}
+
+// evalTerm applies op to the typed entity value and the term value.
+func evalTerm(entityVal any, op string, termVal any, valType string, coll CollatorFunc) (bool, error) {
+	switch valType {
+	case typeBool:
+		ev, ok1 := entityVal.(bool)
+		tv, ok2 := termVal.(bool)
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("type mismatch comparing bool values")
+		}
+		switch op {
+		case opEQ:
+			return ev == tv, nil
+		case opNE:
+			return ev != tv, nil
+		}
+		return false, fmt.Errorf("operator %q not valid for bool", op)
+	case typeInt:
+		ev, ok := entityVal.(int)
+		if !ok {
+			return false, fmt.Errorf("type mismatch comparing int values")
+		}
+		switch tv := termVal.(type) {
+		case int:
+			return compareOrdered(float64(ev), float64(tv), op)
+		case float64:
+			// A decimal threshold against an int attribute compares
+			// exactly, without rounding either side.
+			return compareExact(ev, tv, op)
+		case json.Number:
+			f, err := tv.Float64()
+			if err != nil {
+				return false, fmt.Errorf("type mismatch comparing int values")
+			}
+			return compareExact(ev, f, op)
+		}
+		return false, fmt.Errorf("type mismatch comparing int values")
+	case typeFloat:
+		ev, ok := entityVal.(float64)
+		if !ok {
+			return false, fmt.Errorf("type mismatch comparing float values")
+		}
+		var tv float64
+		switch v := termVal.(type) {
+		case float64:
+			tv = v
+		case int:
+			tv = float64(v)
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return false, fmt.Errorf("type mismatch comparing float values")
+			}
+			tv = f
+		default:
+			return false, fmt.Errorf("type mismatch comparing float values")
+		}
+		return compareOrdered(ev, tv, op)
+	case typeStr, typeEnum:
+		ev, ok1 := entityVal.(string)
+		tv, ok2 := termVal.(string)
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("type mismatch comparing string values")
+		}
+		return compareStrings(ev, tv, op, coll)
+	case typeTS:
+		ev, ok1 := entityVal.(time.Time)
+		tv, ok2 := termVal.(string)
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("type mismatch comparing timestamp values")
+		}
+		tvt, err := time.Parse(timeLayout, tv)
+		if err != nil {
+			return false, err
+		}
+		return compareTimes(ev, tvt, op)
+	case typeDate:
+		ev, ok1 := entityVal.(time.Time)
+		tv, ok2 := termVal.(string)
+		if !ok1 || !ok2 {
+			return false, fmt.Errorf("type mismatch comparing date values")
+		}
+		tvt, err := time.Parse(dateLayout, tv)
+		if err != nil {
+			return false, err
+		}
+		return compareTimes(ev, tvt, op)
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// compareTimes applies an operator to two timestamps.
+func compareTimes(ev, tvt time.Time, op string) (bool, error) {
+	switch op {
+	case opEQ:
+		return ev.Equal(tvt), nil
+	case opNE:
+		return !ev.Equal(tvt), nil
+	case opLT:
+		return ev.Before(tvt), nil
+	case opLE:
+		return !ev.After(tvt), nil
+	case opGT:
+		return ev.After(tvt), nil
+	case opGE:
+		return !ev.Before(tvt), nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// compareOrdered applies an ordering operator to two numbers.
+func compareOrdered(a, b float64, op string) (bool, error) {
+	switch op {
+	case opEQ:
+		return a == b, nil
+	case opNE:
+		return a != b, nil
+	case opLT:
+		return a < b, nil
+	case opLE:
+		return a <= b, nil
+	case opGT:
+		return a > b, nil
+	case opGE:
+		return a >= b, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// collectActions merges a matched rule's actions into the running
+// ActionSet. Tasks are deduplicated; a property set again overwrites the
+// earlier value. Property values pass through the output format declared
+// for them in the action schema, if any.
+func (ec *evalCtx) collectActions(entity Entity, actionSet ActionSet, ra *RuleActions, schema *RuleSchema) (ActionSet, error) {
+	for _, task := range ra.Tasks {
+		found := false
+		for _, t := range actionSet.Tasks {
+			if t == task {
+				found = true
+				break
+			}
+		}
+		if !found {
+			actionSet.Tasks = append(actionSet.Tasks, task)
+		}
+	}
+	if len(ra.Properties) > 0 && actionSet.Properties == nil {
+		actionSet.Properties = map[string]string{}
+	}
+	for prop, val := range ra.Properties {
+		if format, ok := propFormatFor(schema, prop); ok {
+			formatted, err := formatPropertyVal(val, format)
+			if err != nil {
+				return actionSet, fmt.Errorf("property %q: %v", prop, err)
+			}
+			val = formatted
+		}
+		actionSet.Properties[prop] = val
+	}
+	if ra.Enrich != "" {
+		props, err := callEnrichment(ec.context(), ra.Enrich, entity, actionSet, ec.cfg.enrichTimeout)
+		if err != nil {
+			return actionSet, err
+		}
+		if len(props) > 0 && actionSet.Properties == nil {
+			actionSet.Properties = map[string]string{}
+		}
+		for prop, val := range props {
+			if schema != nil && !hasProperty(&schema.ActionSchema, prop) {
+				return actionSet, fmt.Errorf("enrichment %q set undeclared property %q", ra.Enrich, prop)
+			}
+			actionSet.Properties[prop] = val
+		}
+	}
+	return actionSet, nil
+}
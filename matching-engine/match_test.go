@@ -0,0 +1,312 @@
+package crux
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// setupTestSchema loads a schema and ruleset for the inventoryitems test
+// class and returns a teardown func restoring the package-level caches.
+func setupTestSchema(t *testing.T, schema *RuleSchema, rs *RuleSet) func() {
+	t.Helper()
+	savedSchemas := ruleSchemas
+	savedRuleSets := ruleSets
+	ruleSchemas = []*RuleSchema{schema}
+	ruleSets = map[string]*RuleSet{}
+	invalidateSchemaIndex()
+	if rs != nil {
+		ruleSets[rs.SetName] = rs
+	}
+	return func() {
+		ruleSchemas = savedSchemas
+		ruleSets = savedRuleSets
+		invalidateSchemaIndex()
+	}
+}
+
+func inventorySchema() *RuleSchema {
+	return &RuleSchema{
+		Class: "inventoryitems",
+		PatternSchema: []AttrSchema{
+			{Name: "cat", ValType: typeEnum, Vals: map[string]struct{}{"textbook": {}, "notebook": {}}},
+			{Name: "mrp", ValType: typeFloat},
+			{Name: "received", ValType: typeTS},
+		},
+		ActionSchema: ActionSchema{
+			Tasks:      []string{"invitefordiwali"},
+			Properties: []string{"shipby", "discount", "receivedat"},
+		},
+	}
+}
+
+func inventoryEntity() Entity {
+	return Entity{
+		Class: "inventoryitems",
+		Attrs: map[string]string{
+			"cat":      "textbook",
+			"mrp":      "50",
+			"received": "2024-05-01T10:00:00Z",
+		},
+	}
+}
+
+func TestPropFormatApplied(t *testing.T) {
+	schema := inventorySchema()
+	schema.ActionSchema.PropFormats = map[string]string{
+		"receivedat": "rfc3339",
+		"discount":   "decimal(2)",
+	}
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions: RuleActions{
+				Properties: map[string]string{
+					"receivedat": "2024-05-01 10:00:00",
+					"discount":   "7.5",
+				},
+			},
+		}},
+	}
+	defer setupTestSchema(t, schema, rs)()
+
+	actionSet, _, err := doMatch(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if got := actionSet.Properties["receivedat"]; got != "2024-05-01T10:00:00Z" {
+		t.Errorf("receivedat = %q, want RFC3339 form", got)
+	}
+	if got := actionSet.Properties["discount"]; got != "7.50" {
+		t.Errorf("discount = %q, want \"7.50\"", got)
+	}
+}
+
+func TestPropFormatValidatedAtSave(t *testing.T) {
+	schema := inventorySchema()
+	schema.ActionSchema.PropFormats = map[string]string{"discount": "roman-numerals"}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted unknown output format")
+	}
+	schema.ActionSchema.PropFormats = map[string]string{"discount": "decimal(2)"}
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Errorf("verifyRuleSchema rejected valid format: %v", err)
+	}
+}
+
+func TestFanOutCapExceeded(t *testing.T) {
+	schema := inventorySchema()
+	defer setupTestSchema(t, schema, nil)()
+	// Chain five rulesets head -> c1 -> ... -> c4 via THENCALL.
+	names := []string{"head", "c1", "c2", "c3", "c4"}
+	for i, name := range names {
+		rs := &RuleSet{
+			Class:   "inventoryitems",
+			SetName: name,
+			Rules: []Rule{{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			}},
+		}
+		if i+1 < len(names) {
+			rs.Rules[0].RuleActions.ThenCall = names[i+1]
+		}
+		ruleSets[name] = rs
+	}
+
+	cfg := matchConfig{maxFanOut: 3}
+	_, _, err := doMatchWithConfig(inventoryEntity(), ruleSets["head"], ActionSet{}, map[string]struct{}{}, cfg)
+	if err == nil {
+		t.Fatalf("expected fan-out cap error, got none")
+	}
+	if want := "fan-out cap of 3"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not mention %q", err, want)
+	}
+
+	// The same chain fits under a higher cap.
+	if _, _, err := doMatchWithConfig(inventoryEntity(), ruleSets["head"], ActionSet{}, map[string]struct{}{}, matchConfig{maxFanOut: 10}); err != nil {
+		t.Errorf("unexpected error under higher cap: %v", err)
+	}
+
+	// The cap is reachable per request through the exported options.
+	if _, err := MatchWithOptions(context.Background(), "head", inventoryEntity(), MatchOptions{MaxFanOut: 3}); err == nil {
+		t.Errorf("exported options did not apply the fan-out cap")
+	}
+	if _, err := MatchWithOptions(context.Background(), "head", inventoryEntity(), MatchOptions{MaxFanOut: 10}); err != nil {
+		t.Errorf("unexpected error via exported options under higher cap: %v", err)
+	}
+
+	if MetricsSnapshot()["fanout_exceeded"] < 1 {
+		t.Errorf("fanout_exceeded metric not incremented")
+	}
+}
+
+func TestRequiredTasksPostCondition(t *testing.T) {
+	schema := inventorySchema()
+	schema.ActionSchema.RequiredTasks = []string{"invitefordiwali"}
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			// Matches only notebooks, so a textbook entity produces no tasks.
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	defer setupTestSchema(t, schema, rs)()
+
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+
+	cfg := matchConfig{checkRequired: true}
+	_, _, err := doMatchWithConfig(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "required tasks") {
+		t.Errorf("missing required task not flagged, err = %v", err)
+	}
+
+	// Without the opt-in check the evaluation succeeds.
+	if _, _, err := doMatch(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{}); err != nil {
+		t.Errorf("unexpected error without post-condition: %v", err)
+	}
+
+	// The check is reachable per request through the exported options.
+	_, err = MatchWithOptions(context.Background(), "main", inventoryEntity(), MatchOptions{CheckRequired: true})
+	if err == nil || !strings.Contains(err.Error(), "required tasks") {
+		t.Errorf("exported options did not enable the post-condition, err = %v", err)
+	}
+
+	// A required task outside the declared tasks is a schema error.
+	schema.ActionSchema.RequiredTasks = []string{"notdeclared"}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted undeclared required task")
+	}
+}
+
+func TestMinConfidenceThreshold(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns:  []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:   RuleActions{Tasks: []string{"invitefordiwali"}},
+			MinConfidence: 0.8,
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	e := inventoryEntity()
+	e.Confidence = map[string]float64{"cat": 0.9}
+	actionSet, _, err := doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("rule did not fire above threshold")
+	}
+
+	e.Confidence["cat"] = 0.5
+	actionSet, _, err = doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("rule fired below threshold")
+	}
+
+	rs.Rules[0].MinConfidence = 1.5
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("verifyRuleSet accepted threshold outside [0,1]")
+	}
+}
+
+func TestMinTrustSkipsRule(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			MinTrust:     0.7,
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+
+	e := inventoryEntity()
+	e.Provenance = map[string]AttrSource{"cat": {Source: "ocr-scan", Trust: 0.4}}
+	if err := verifyEntity(e); err != nil {
+		t.Fatalf("verifyEntity: %v", err)
+	}
+	actionSet, _, err := doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("rule fired despite low source trust")
+	}
+
+	e.Provenance["cat"] = AttrSource{Source: "registry", Trust: 0.95}
+	actionSet, _, err = doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("rule did not fire with trusted source")
+	}
+
+	// Malformed provenance is rejected by verifyEntity.
+	e.Provenance["cat"] = AttrSource{Trust: 0.5}
+	if err := verifyEntity(e); err == nil {
+		t.Errorf("verifyEntity accepted provenance without a source")
+	}
+}
+
+func TestOrderedEnumComparison(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "incident",
+		PatternSchema: []AttrSchema{
+			{
+				Name: "severity", ValType: typeEnum,
+				Vals:        map[string]struct{}{"low": {}, "medium": {}, "high": {}},
+				OrderedVals: []string{"low", "medium", "high"},
+			},
+		},
+		ActionSchema: ActionSchema{Tasks: []string{"page"}},
+	}
+	rs := &RuleSet{
+		Class:   "incident",
+		SetName: "oncall",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "severity", Op: opGE, Val: "medium"}},
+			RuleActions:  RuleActions{Tasks: []string{"page"}},
+		}},
+	}
+	defer setupTestSchema(t, schema, rs)()
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	for severity, want := range map[string]bool{"high": true, "medium": true, "low": false} {
+		e := Entity{Class: "incident", Attrs: map[string]string{"severity": severity}}
+		actionSet, _, err := doMatch(e, rs, ActionSet{}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch(%s): %v", severity, err)
+		}
+		if got := len(actionSet.Tasks) == 1; got != want {
+			t.Errorf("severity=%s fired=%v, want %v", severity, got, want)
+		}
+	}
+
+	// Ordering operators stay illegal for unordered enums.
+	schema.PatternSchema[0].OrderedVals = nil
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Errorf("verifyRuleSet allowed >= on unordered enum")
+	}
+}
@@ -0,0 +1,116 @@
+package crux
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// engineMetrics accumulates counters across all evaluations since
+// process start.
+var engineMetrics struct {
+	ruleSetInvocations atomic.Int64 // rulesets entered, across all evaluations
+	fanOutExceeded     atomic.Int64 // evaluations aborted by the fan-out cap
+	unknownAttrRules   atomic.Int64 // rules hit whose attributes have left the schema
+}
+
+// MetricsSnapshot returns the engine counters for export by whatever
+// metrics plumbing the caller runs.
+func MetricsSnapshot() map[string]int64 {
+	return map[string]int64{
+		"ruleset_invocations": engineMetrics.ruleSetInvocations.Load(),
+		"fanout_exceeded":     engineMetrics.fanOutExceeded.Load(),
+		"unknown_attr_rules":  engineMetrics.unknownAttrRules.Load(),
+	}
+}
+
+// durationBuckets are the upper bounds, in seconds, of the evaluation
+// latency histogram, chosen to straddle the microseconds-to-seconds
+// range one evaluation can plausibly take.
+var durationBuckets = []float64{0.0001, 0.001, 0.01, 0.1, 1}
+
+// RuleSetMetrics counts the evaluations of one ruleset and how many of
+// them fired at least one rule.
+type RuleSetMetrics struct {
+	Evaluations int64 `json:"evaluations"`
+	Matched     int64 `json:"matched"`
+	NoMatch     int64 `json:"nomatch"`
+}
+
+// EvalMetrics is a point-in-time copy of the per-evaluation statistics:
+// counts per ruleset, the latency histogram, and THENCALL/ELSECALL
+// recursion depth. DurationCounts[i] counts evaluations at or under
+// DurationBuckets[i]; evaluations beyond the last bucket only appear in
+// DurationCount and DurationSum.
+type EvalMetrics struct {
+	PerRuleSet      map[string]RuleSetMetrics `json:"perruleset"`
+	DurationBuckets []float64                 `json:"durationbuckets"`
+	DurationCounts  []int64                   `json:"durationcounts"`
+	DurationSum     float64                   `json:"durationsum"`
+	DurationCount   int64                     `json:"durationcount"`
+	DepthSum        int64                     `json:"depthsum"`
+	DepthMax        int64                     `json:"depthmax"`
+}
+
+// evalStats accumulates EvalMetrics under one mutex. Observation is a
+// map update and a handful of adds, so the lock is cheap next to the
+// evaluation it accounts for.
+var evalStats = struct {
+	mu             sync.Mutex
+	perSet         map[string]*RuleSetMetrics
+	durationCounts [6]int64
+	durationSum    float64
+	durationCount  int64
+	depthSum       int64
+	depthMax       int64
+}{perSet: map[string]*RuleSetMetrics{}}
+
+// observeEvaluation records one finished top-level evaluation.
+func observeEvaluation(setName string, matched bool, dur time.Duration, depth int) {
+	secs := dur.Seconds()
+	evalStats.mu.Lock()
+	defer evalStats.mu.Unlock()
+	m := evalStats.perSet[setName]
+	if m == nil {
+		m = &RuleSetMetrics{}
+		evalStats.perSet[setName] = m
+	}
+	m.Evaluations++
+	if matched {
+		m.Matched++
+	} else {
+		m.NoMatch++
+	}
+	for i, le := range durationBuckets {
+		if secs <= le {
+			evalStats.durationCounts[i]++
+		}
+	}
+	evalStats.durationSum += secs
+	evalStats.durationCount++
+	evalStats.depthSum += int64(depth)
+	if int64(depth) > evalStats.depthMax {
+		evalStats.depthMax = int64(depth)
+	}
+}
+
+// EvalMetricsSnapshot copies the per-evaluation statistics out for
+// export; see the server's /metrics endpoint for the Prometheus text
+// rendering.
+func EvalMetricsSnapshot() EvalMetrics {
+	evalStats.mu.Lock()
+	defer evalStats.mu.Unlock()
+	out := EvalMetrics{
+		PerRuleSet:      make(map[string]RuleSetMetrics, len(evalStats.perSet)),
+		DurationBuckets: durationBuckets,
+		DurationCounts:  append([]int64(nil), evalStats.durationCounts[:len(durationBuckets)]...),
+		DurationSum:     evalStats.durationSum,
+		DurationCount:   evalStats.durationCount,
+		DepthSum:        evalStats.depthSum,
+		DepthMax:        evalStats.depthMax,
+	}
+	for name, m := range evalStats.perSet {
+		out.PerRuleSet[name] = *m
+	}
+	return out
+}
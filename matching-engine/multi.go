@@ -0,0 +1,99 @@
+package crux
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Evaluation statuses in a multi-ruleset result.
+const (
+	multiOK      = "ok"
+	multiTimeout = "timeout"
+	multiError   = "error"
+)
+
+// MultiResult is one ruleset's outcome within a multi-evaluation:
+// composite decisions get partial results, with each ruleset marked ok,
+// timeout or error rather than one slow ruleset sinking the batch.
+type MultiResult struct {
+	SetName   string    `json:"setname"`
+	Status    string    `json:"status"`
+	ActionSet ActionSet `json:"actionset"`
+	Err       string    `json:"err,omitempty"`
+}
+
+// MatchMulti evaluates one verified entity against several independent
+// rulesets concurrently, at most maxConcurrent at a time (0 means all at
+// once), each bounded by perSetTimeout (0 means no deadline). Results
+// come back in input order, each marked ok, timeout or error.
+func MatchMulti(ctx context.Context, setNames []string, entity Entity, maxConcurrent int, perSetTimeout time.Duration) ([]MultiResult, error) {
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return multiMatch(ctx, entity, setNames, maxConcurrent, perSetTimeout), nil
+}
+
+// multiMatch evaluates the entity against several independent rulesets
+// concurrently, at most maxConcurrent at a time (0 means all at once),
+// each bounded by perSetTimeout (0 means no deadline). Results come back
+// in input order.
+func multiMatch(ctx context.Context, entity Entity, setNames []string, maxConcurrent int, perSetTimeout time.Duration) []MultiResult {
+	results := make([]MultiResult, len(setNames))
+	sem := make(chan struct{}, concurrencyCap(maxConcurrent, len(setNames)))
+	var wg sync.WaitGroup
+	for i, setName := range setNames {
+		wg.Add(1)
+		go func(i int, setName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = evalOneSet(ctx, entity, setName, perSetTimeout)
+		}(i, setName)
+	}
+	wg.Wait()
+	return results
+}
+
+// evalOneSet runs one ruleset of a multi-evaluation under its own
+// deadline and classifies the outcome.
+func evalOneSet(ctx context.Context, entity Entity, setName string, timeout time.Duration) MultiResult {
+	result := MultiResult{SetName: setName}
+	rs, ok := ruleSets[setName]
+	if !ok {
+		result.Status = multiError
+		result.Err = "no such ruleset"
+		return result
+	}
+	setCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		setCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	actionSet, _, err := doMatchContext(setCtx, entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, matchConfig{})
+	if err != nil {
+		if setCtx.Err() == context.DeadlineExceeded {
+			result.Status = multiTimeout
+		} else {
+			result.Status = multiError
+		}
+		result.Err = err.Error()
+		return result
+	}
+	result.Status = multiOK
+	result.ActionSet = actionSet
+	return result
+}
+
+// concurrencyCap sizes the semaphore for a multi-evaluation.
+func concurrencyCap(maxConcurrent, n int) int {
+	if maxConcurrent > 0 && maxConcurrent < n {
+		return maxConcurrent
+	}
+	if n == 0 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package crux
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// One slow ruleset exhausting its per-set deadline must come back marked
+// timeout while the rulesets that finished return their results intact.
+func TestMultiMatchPartialResults(t *testing.T) {
+	RegisterEnrichmentFunc("slowlookup", func(ctx context.Context, entity Entity, actionSet ActionSet) (map[string]string, error) {
+		time.Sleep(200 * time.Millisecond)
+		return nil, nil
+	})
+	defer delete(enrichmentFuncs, "slowlookup")
+
+	fast := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "multifast",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	slow := &RuleSet{
+		Id:      2,
+		Class:   "inventoryitems",
+		SetName: "multislow",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Enrich: "slowlookup"},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), fast)
+	defer teardown()
+	ruleSets[slow.SetName] = slow
+
+	results, err := MatchMulti(context.Background(),
+		[]string{"multifast", "multislow", "nosuchset"}, inventoryEntity(), 2, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("MatchMulti: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[0].Status != multiOK || len(results[0].ActionSet.Tasks) != 1 {
+		t.Errorf("fast ruleset: got status %q actionset %+v", results[0].Status, results[0].ActionSet)
+	}
+	if results[1].Status != multiTimeout {
+		t.Errorf("slow ruleset: got status %q (%s), want timeout", results[1].Status, results[1].Err)
+	}
+	if results[2].Status != multiError {
+		t.Errorf("missing ruleset: got status %q, want error", results[2].Status)
+	}
+}
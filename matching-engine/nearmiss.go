@@ -0,0 +1,72 @@
+package crux
+
+import "fmt"
+
+// NearMiss describes a rule that failed by exactly one pattern term: the
+// term that blocked it and the actual value it saw against the value it
+// wanted. Authors use it to answer "why didn't my rule fire?".
+type NearMiss struct {
+	RuleIdx  int    `json:"ruleidx"`
+	Attr     string `json:"attr"`
+	Op       string `json:"op"`
+	Expected any    `json:"expected"`
+	Actual   string `json:"actual"` // empty when the attribute was missing
+}
+
+// NearMisses reports the rules of a loaded ruleset that all but matched
+// the entity — every term held except one — verifying the entity first.
+func NearMisses(setName string, entity Entity) ([]NearMiss, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return nil, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return nearMisses(entity, rs)
+}
+
+// nearMisses re-evaluates each rule of the ruleset term by term and
+// reports the rules that all but matched — every term held except one.
+// Rules that matched, or missed by two or more terms, produce nothing.
+func nearMisses(entity Entity, rs *RuleSet) ([]NearMiss, error) {
+	schema := schemaFor(rs)
+	if schema == nil {
+		return nil, fmt.Errorf("no schema found for class %q", rs.Class)
+	}
+	actionSet := ActionSet{Properties: map[string]string{}}
+	var misses []NearMiss
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if len(rule.RulePatterns) < 2 {
+			continue // a one-term rule cannot "almost" match
+		}
+		blocking := -1
+		failed := 0
+		for j := range rule.RulePatterns {
+			term := rule.RulePatterns[j : j+1]
+			holds, _, err := matchPattern(entity, term, actionSet, schema)
+			if err != nil {
+				return nil, err
+			}
+			if !holds {
+				failed++
+				blocking = j
+			}
+		}
+		if failed != 1 {
+			continue
+		}
+		term := &rule.RulePatterns[blocking]
+		actual, _ := getEntityAttrVal(entity, actionSet, term.Attr)
+		misses = append(misses, NearMiss{
+			RuleIdx:  i,
+			Attr:     term.Attr,
+			Op:       term.Op,
+			Expected: term.Val,
+			Actual:   actual,
+		})
+	}
+	return misses, nil
+}
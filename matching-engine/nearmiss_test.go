@@ -0,0 +1,51 @@
+package crux
+
+import "testing"
+
+// A rule blocked by exactly one term is reported with the blocking term
+// and the actual vs expected value; rules that matched or missed by more
+// than one term are not.
+func TestNearMissDiagnostic(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "nearmissset",
+		Rules: []Rule{
+			{ // one term off: mrp is 50, not > 100
+				RulePatterns: []RulePatternTerm{
+					{Attr: "cat", Op: opEQ, Val: "textbook"},
+					{Attr: "mrp", Op: opGT, Val: 100.0},
+				},
+			},
+			{ // two terms off: not a near miss
+				RulePatterns: []RulePatternTerm{
+					{Attr: "cat", Op: opEQ, Val: "notebook"},
+					{Attr: "mrp", Op: opGT, Val: 100.0},
+				},
+			},
+			{ // matches outright: not a near miss
+				RulePatterns: []RulePatternTerm{
+					{Attr: "cat", Op: opEQ, Val: "textbook"},
+					{Attr: "mrp", Op: opLT, Val: 100.0},
+				},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	misses, err := NearMisses("nearmissset", inventoryEntity())
+	if err != nil {
+		t.Fatalf("NearMisses: %v", err)
+	}
+	if len(misses) != 1 {
+		t.Fatalf("got %d near misses, want 1: %+v", len(misses), misses)
+	}
+	miss := misses[0]
+	if miss.RuleIdx != 0 || miss.Attr != "mrp" || miss.Op != opGT {
+		t.Fatalf("wrong blocking term reported: %+v", miss)
+	}
+	if miss.Actual != "50" || miss.Expected != 100.0 {
+		t.Fatalf("actual/expected not captured: %+v", miss)
+	}
+}
@@ -0,0 +1,57 @@
+package crux
+
+import "testing"
+
+// A negated term inverts its operator's result, while an absent
+// attribute still fails the pattern regardless of negation.
+func TestNegatedTerms(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "negateset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{
+				{Attr: "cat", Op: opIN, Val: []any{"notebook"}, Negate: true},
+			},
+			RuleActions: RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	match := func(cat string) int {
+		entity := inventoryEntity()
+		if cat == "" {
+			delete(entity.Attrs, "cat")
+		} else {
+			entity.Attrs["cat"] = cat
+		}
+		actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+		if err != nil {
+			t.Fatalf("doMatch cat=%q: %v", cat, err)
+		}
+		return len(actionSet.Tasks)
+	}
+
+	if got := match("textbook"); got != 1 {
+		t.Errorf("textbook NOT IN [notebook] should match, got %d tasks", got)
+	}
+	if got := match("notebook"); got != 0 {
+		t.Errorf("notebook NOT IN [notebook] should not match, got %d tasks", got)
+	}
+	if got := match(""); got != 0 {
+		t.Errorf("absent attribute matched through negation, got %d tasks", got)
+	}
+
+	// Negation composes with any operator, here a plain equality.
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "cat", Op: opEQ, Val: "notebook", Negate: true}
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet negated eq: %v", err)
+	}
+	if got := match("textbook"); got != 1 {
+		t.Errorf("negated eq should match textbook, got %d tasks", got)
+	}
+}
@@ -0,0 +1,55 @@
+package crux
+
+import "sync"
+
+// Change kinds published on schema and ruleset saves.
+const (
+	changeKindSchema  = "schema"
+	changeKindRuleSet = "ruleset"
+)
+
+// ChangeEvent announces that a schema or ruleset changed, so other
+// engine instances caching either can invalidate. Name is the class for
+// a schema change and the set name for a ruleset change.
+type ChangeEvent struct {
+	Realm   string `json:"realm"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// changeWatchers holds the registered change subscribers.
+var changeWatchers = struct {
+	mu     sync.Mutex
+	nextID int
+	fns    map[int]func(ChangeEvent)
+}{fns: map[int]func(ChangeEvent){}}
+
+// WatchChanges subscribes to schema and ruleset change events and
+// returns a function that cancels the subscription. Watchers run
+// synchronously on the saving goroutine and must be fast.
+func WatchChanges(fn func(ChangeEvent)) func() {
+	changeWatchers.mu.Lock()
+	defer changeWatchers.mu.Unlock()
+	id := changeWatchers.nextID
+	changeWatchers.nextID++
+	changeWatchers.fns[id] = fn
+	return func() {
+		changeWatchers.mu.Lock()
+		defer changeWatchers.mu.Unlock()
+		delete(changeWatchers.fns, id)
+	}
+}
+
+// publishChange delivers an event to every registered watcher.
+func publishChange(ev ChangeEvent) {
+	changeWatchers.mu.Lock()
+	fns := make([]func(ChangeEvent), 0, len(changeWatchers.fns))
+	for _, fn := range changeWatchers.fns {
+		fns = append(fns, fn)
+	}
+	changeWatchers.mu.Unlock()
+	for _, fn := range fns {
+		fn(ev)
+	}
+}
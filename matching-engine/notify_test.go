@@ -0,0 +1,47 @@
+package crux
+
+import "testing"
+
+// A ruleset save publishes a change event, and a watching "instance"
+// invalidates its local cache in response; an unsubscribed watcher hears
+// nothing more.
+func TestChangeNotificationInvalidatesCache(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+
+	// A second instance's local cache of compiled rulesets.
+	localCache := map[string]*RuleSet{"changeset": {SetName: "changeset"}}
+	var events []ChangeEvent
+	cancel := WatchChanges(func(ev ChangeEvent) {
+		events = append(events, ev)
+		if ev.Kind == changeKindRuleSet {
+			delete(localCache, ev.Name)
+		}
+	})
+	defer cancel()
+
+	rs := &RuleSet{
+		Id:      1,
+		Realm:   "acme",
+		Class:   "inventoryitems",
+		SetName: "changeset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	ReplaceRuleSet(rs)
+
+	if len(events) != 1 || events[0].Kind != changeKindRuleSet || events[0].Name != "changeset" || events[0].Realm != "acme" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if _, ok := localCache["changeset"]; ok {
+		t.Errorf("watching instance did not invalidate its cache")
+	}
+
+	cancel()
+	ReplaceRuleSet(rs)
+	if len(events) != 1 {
+		t.Errorf("unsubscribed watcher still received events: %+v", events)
+	}
+}
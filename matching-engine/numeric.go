@@ -0,0 +1,35 @@
+package crux
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// compareExact compares an int attribute value against a decimal term
+// value without converting the int to a float first, so thresholds like
+// 10.5 against large ints compare exactly. Both sides are lifted to
+// rationals: an int64 embeds exactly, and a float64 is itself an exact
+// binary rational.
+func compareExact(ev int, tv float64, op string) (bool, error) {
+	a := new(big.Rat).SetInt64(int64(ev))
+	b := new(big.Rat).SetFloat64(tv)
+	if b == nil {
+		return false, fmt.Errorf("decimal value %v is not finite", tv)
+	}
+	cmp := a.Cmp(b)
+	switch op {
+	case opEQ:
+		return cmp == 0, nil
+	case opNE:
+		return cmp != 0, nil
+	case opLT:
+		return cmp < 0, nil
+	case opLE:
+		return cmp <= 0, nil
+	case opGT:
+		return cmp > 0, nil
+	case opGE:
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
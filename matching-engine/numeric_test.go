@@ -0,0 +1,53 @@
+package crux
+
+import "testing"
+
+// An int attribute compares exactly against a decimal threshold: 10000
+// is not greater than 10000.5, and equality against a fractional value
+// never holds for an int.
+func TestIntDecimalComparison(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "stock", ValType: typeInt})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "decimalset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "stock", Op: opGT, Val: 10000.5}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("verifyRuleSet: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["stock"] = "10000"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("10000 compared greater than 10000.5: %+v", actionSet)
+	}
+
+	entity.Attrs["stock"] = "10001"
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("10001 did not compare greater than 10000.5: %+v", actionSet)
+	}
+
+	holds, err := compareExact(7, 7.0, opEQ)
+	if err != nil || !holds {
+		t.Errorf("7 != 7.0 under exact comparison: %v %v", holds, err)
+	}
+	holds, err = compareExact(7, 7.5, opEQ)
+	if err != nil || holds {
+		t.Errorf("7 == 7.5 under exact comparison: %v %v", holds, err)
+	}
+}
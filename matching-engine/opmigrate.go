@@ -0,0 +1,81 @@
+package crux
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OpMigrationChange records one term rewrite performed (or proposed) by
+// an operator migration.
+type OpMigrationChange struct {
+	SetName string `json:"setname"`
+	RuleIdx int    `json:"ruleidx"`
+	TermIdx int    `json:"termidx"`
+	OldOp   string `json:"oldop"`
+	NewOp   string `json:"newop"`
+}
+
+// MigrateOperators rewrites deprecated operators across a realm's loaded
+// rulesets per the supplied old-to-new mapping, for engine evolution
+// that renames or retires an operator. Every rewritten ruleset is
+// re-verified before any is saved, so a mapping that produces an invalid
+// ruleset leaves the realm untouched. With dryRun the rewrites are only
+// reported, never applied. The returned changes are ordered by set name.
+func MigrateOperators(realm string, mapping map[string]string, dryRun bool) ([]OpMigrationChange, error) {
+	names := make([]string, 0, len(ruleSets))
+	for name, rs := range ruleSets {
+		if rs.Realm == realm {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []OpMigrationChange
+	var rewritten []*RuleSet
+	for _, name := range names {
+		rs := ruleSets[name]
+		copied, setChanges := rewriteOps(rs, mapping)
+		if len(setChanges) == 0 {
+			continue
+		}
+		if err := verifyRuleSet(copied, false); err != nil {
+			return nil, fmt.Errorf("operator migration of realm %q: rewritten ruleset %q does not verify: %v", realm, name, err)
+		}
+		changes = append(changes, setChanges...)
+		rewritten = append(rewritten, copied)
+	}
+	if !dryRun {
+		for _, rs := range rewritten {
+			if err := ReplaceRuleSet(rs); err != nil {
+				return nil, fmt.Errorf("operator migration of realm %q: %v", realm, err)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// rewriteOps returns a copy of rs with every operator in the mapping
+// replaced, plus the rewrites made. The original is never modified, so
+// a dry run or a failed verification has nothing to undo.
+func rewriteOps(rs *RuleSet, mapping map[string]string) (*RuleSet, []OpMigrationChange) {
+	copied := *rs
+	copied.Rules = make([]Rule, len(rs.Rules))
+	var changes []OpMigrationChange
+	for i, rule := range rs.Rules {
+		copied.Rules[i] = rule
+		copied.Rules[i].RulePatterns = make([]RulePatternTerm, len(rule.RulePatterns))
+		for j, term := range rule.RulePatterns {
+			if newOp, ok := mapping[term.Op]; ok {
+				changes = append(changes, OpMigrationChange{
+					SetName: rs.SetName,
+					RuleIdx: i,
+					TermIdx: j,
+					OldOp:   term.Op,
+					NewOp:   newOp,
+				})
+				term.Op = newOp
+			}
+			copied.Rules[i].RulePatterns[j] = term
+		}
+	}
+	return &copied, changes
+}
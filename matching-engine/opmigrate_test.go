@@ -0,0 +1,55 @@
+package crux
+
+import "testing"
+
+// An operator migration rewrites a deprecated operator across all of a
+// realm's rulesets; a dry run reports the rewrites without applying
+// them, and a mapping yielding an invalid ruleset applies nothing.
+func TestMigrateOperators(t *testing.T) {
+	mkSet := func(id int, name string) *RuleSet {
+		return &RuleSet{
+			Id:      id,
+			Realm:   "acme",
+			Class:   "inventoryitems",
+			SetName: name,
+			Rules: []Rule{{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: "equals", Val: "textbook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			}},
+		}
+	}
+	teardown := setupTestSchema(t, inventorySchema(), mkSet(1, "migsetone"))
+	defer teardown()
+	ruleSets["migsettwo"] = mkSet(2, "migsettwo")
+
+	changes, err := MigrateOperators("acme", map[string]string{"equals": opEQ}, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if ruleSets["migsetone"].Rules[0].RulePatterns[0].Op != "equals" {
+		t.Errorf("dry run modified a stored ruleset")
+	}
+
+	if _, err := MigrateOperators("acme", map[string]string{"equals": "bogus"}, false); err == nil {
+		t.Fatalf("mapping to an unknown operator accepted")
+	}
+	if ruleSets["migsetone"].Rules[0].RulePatterns[0].Op != "equals" {
+		t.Errorf("failed migration modified a stored ruleset")
+	}
+
+	changes, err = MigrateOperators("acme", map[string]string{"equals": opEQ}, false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes on apply, got %+v", changes)
+	}
+	for _, name := range []string{"migsetone", "migsettwo"} {
+		if op := ruleSets[name].Rules[0].RulePatterns[0].Op; op != opEQ {
+			t.Errorf("ruleset %q not rewritten: op %q", name, op)
+		}
+	}
+}
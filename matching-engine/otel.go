@@ -0,0 +1,73 @@
+package crux
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Span is the OpenTelemetry-shaped record the engine emits per ruleset
+// invocation: one root span per evaluation and a child span for every
+// THENCALL/ELSECALL descent. TraceID carries the incoming request's
+// trace context (the correlation ID) so spans link up with the rest of
+// the distributed trace.
+type Span struct {
+	Name         string
+	TraceID      string
+	SpanID       int64
+	ParentSpanID int64 // 0 for the root span
+	Class        string
+	RuleSet      string
+	RulesMatched int
+	Start        time.Time
+	End          time.Time
+}
+
+// SpanExporter receives finished spans. Adapters wrap the real OTel SDK
+// exporter outside this package; tests use an in-memory implementation.
+type SpanExporter interface {
+	ExportSpan(Span)
+}
+
+// spanExporter is the configured exporter; nil means spans are not
+// emitted at all, which is the default.
+var spanExporter SpanExporter
+
+// SetSpanExporter installs the span exporter used for all evaluations.
+// Pass nil to return to the no-op default.
+func SetSpanExporter(e SpanExporter) {
+	spanExporter = e
+}
+
+var spanIDCounter atomic.Int64
+
+// startSpan opens a span for one ruleset invocation, parented on the
+// invocation the evaluation is currently inside. Returns nil when no
+// exporter is configured.
+func (ec *evalCtx) startSpan(ruleSet *RuleSet) *Span {
+	if spanExporter == nil {
+		return nil
+	}
+	span := &Span{
+		Name:    "crux.eval/" + ruleSet.SetName,
+		TraceID: ec.cfg.correlationID,
+		SpanID:  spanIDCounter.Add(1),
+		Class:   ruleSet.Class,
+		RuleSet: ruleSet.SetName,
+		Start:   time.Now(),
+	}
+	if ec.curSpan != nil {
+		span.ParentSpanID = ec.curSpan.SpanID
+	}
+	ec.curSpan = span
+	return span
+}
+
+// endSpan finishes and exports a span opened by startSpan.
+func (ec *evalCtx) endSpan(span *Span, parent *Span) {
+	if span == nil {
+		return
+	}
+	span.End = time.Now()
+	ec.curSpan = parent
+	spanExporter.ExportSpan(*span)
+}
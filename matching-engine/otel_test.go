@@ -0,0 +1,62 @@
+package crux
+
+import "testing"
+
+// memExporter collects spans in memory for assertions.
+type memExporter struct {
+	spans []Span
+}
+
+func (m *memExporter) ExportSpan(s Span) { m.spans = append(m.spans, s) }
+
+func TestSpanHierarchyPerInvocation(t *testing.T) {
+	exp := &memExporter{}
+	SetSpanExporter(exp)
+	defer SetSpanExporter(nil)
+
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	ruleSets["child"] = &RuleSet{
+		Class: "inventoryitems", SetName: "child",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 10.0}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	head := &RuleSet{
+		Class: "inventoryitems", SetName: "head",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{ThenCall: "child"},
+		}},
+	}
+	ruleSets["head"] = head
+
+	cfg := matchConfig{correlationID: "trace-42"}
+	if _, _, err := doMatchWithConfig(inventoryEntity(), head, ActionSet{}, map[string]struct{}{}, cfg); err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+
+	if len(exp.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(exp.spans))
+	}
+	// Spans export on finish, so the child comes first.
+	child, root := exp.spans[0], exp.spans[1]
+	if child.RuleSet != "child" || root.RuleSet != "head" {
+		t.Fatalf("span order = %q, %q", child.RuleSet, root.RuleSet)
+	}
+	if root.ParentSpanID != 0 {
+		t.Errorf("root span has parent %d", root.ParentSpanID)
+	}
+	if child.ParentSpanID != root.SpanID {
+		t.Errorf("child parent = %d, want root span %d", child.ParentSpanID, root.SpanID)
+	}
+	if root.TraceID != "trace-42" || child.TraceID != "trace-42" {
+		t.Errorf("spans not linked to incoming trace context")
+	}
+	if root.RulesMatched != 1 || child.RulesMatched != 1 {
+		t.Errorf("rules matched: root %d child %d, want 1 each", root.RulesMatched, child.RulesMatched)
+	}
+	if child.End.Before(child.Start) {
+		t.Errorf("child span has no duration")
+	}
+}
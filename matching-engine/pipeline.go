@@ -0,0 +1,90 @@
+package crux
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Transform stages a schema's pipeline may chain. An enrich stage is
+// written "enrich:<name>", naming a registered enrichment function.
+const (
+	stageCanonicalize = "canonicalize"
+	stageDefaults     = "defaults"
+	stageDerive       = "derive"
+	stageEnrichPrefix = "enrich:"
+)
+
+// verifyPipeline checks a schema's transformation pipeline at save:
+// every stage must be a supported transform, and an enrich stage must
+// name a registered function.
+func verifyPipeline(schema *RuleSchema) error {
+	for i, stage := range schema.Pipeline {
+		switch {
+		case stage == stageCanonicalize, stage == stageDefaults, stage == stageDerive:
+		case strings.HasPrefix(stage, stageEnrichPrefix):
+			name := strings.TrimPrefix(stage, stageEnrichPrefix)
+			if _, ok := enrichmentFuncs[name]; !ok {
+				return fmt.Errorf("schema for class %q: pipeline stage %d: enrichment function %q not registered", schema.Class, i, name)
+			}
+		default:
+			return fmt.Errorf("schema for class %q: pipeline stage %d: unknown transform %q", schema.Class, i, stage)
+		}
+	}
+	return nil
+}
+
+// prepareEntity runs the schema's transformation pipeline over a copy of
+// the entity's attributes, in declared order, returning the entity the
+// evaluation should actually see. A schema without a pipeline returns
+// the entity unchanged.
+func prepareEntity(entity Entity, schema *RuleSchema) (Entity, error) {
+	if len(schema.Pipeline) == 0 {
+		return entity, nil
+	}
+	attrs := make(map[string]string, len(entity.Attrs))
+	for k, v := range entity.Attrs {
+		attrs[k] = v
+	}
+	entity.Attrs = attrs
+	for _, stage := range schema.Pipeline {
+		switch {
+		case stage == stageCanonicalize:
+			for i := range schema.PatternSchema {
+				as := &schema.PatternSchema[i]
+				if val, ok := attrs[as.Name]; ok {
+					attrs[as.Name] = canonicalizeVal(val, as)
+				}
+			}
+		case stage == stageDefaults:
+			for i := range schema.PatternSchema {
+				as := &schema.PatternSchema[i]
+				if _, ok := attrs[as.Name]; !ok && as.Default != "" {
+					attrs[as.Name] = as.Default
+				}
+			}
+		case stage == stageDerive:
+			for i := range schema.PatternSchema {
+				as := &schema.PatternSchema[i]
+				if as.Derive == nil {
+					continue
+				}
+				val, err := deriveAttrVal(attrs, as.Derive, OverflowError)
+				if err != nil {
+					return Entity{}, fmt.Errorf("pipeline stage %q: %v", stage, err)
+				}
+				attrs[as.Name] = val
+			}
+		case strings.HasPrefix(stage, stageEnrichPrefix):
+			name := strings.TrimPrefix(stage, stageEnrichPrefix)
+			props, err := callEnrichment(context.Background(), name, entity, ActionSet{}, 0)
+			if err != nil {
+				return Entity{}, fmt.Errorf("pipeline stage %q: %v", stage, err)
+			}
+			for k, v := range props {
+				attrs[k] = v
+			}
+		}
+	}
+	return entity, nil
+}
@@ -0,0 +1,58 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+// A multi-stage pipeline prepares the entity in declared order: defaults
+// fill missing attributes, derivation computes from them, and enrichment
+// adds looked-up attributes, all before matching sees the entity.
+func TestEntityPipeline(t *testing.T) {
+	RegisterEnrichmentFunc("warehouse", func(ctx context.Context, entity Entity, actionSet ActionSet) (map[string]string, error) {
+		return map[string]string{"warehouse": "pune"}, nil
+	})
+	defer delete(enrichmentFuncs, "warehouse")
+
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "qty", ValType: typeInt, Default: "3"},
+		AttrSchema{Name: "price", ValType: typeInt},
+		AttrSchema{Name: "linetotal", ValType: typeInt, Derive: &DeriveSpec{Op: deriveMul, A: "qty", B: "price"}},
+		AttrSchema{Name: "warehouse", ValType: typeStr},
+	)
+	schema.Pipeline = []string{stageDefaults, stageDerive, "enrich:warehouse"}
+	teardown := setupTestSchema(t, schema, nil)
+	defer teardown()
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["price"] = "40"
+	prepared, err := prepareEntity(entity, schema)
+	if err != nil {
+		t.Fatalf("prepareEntity: %v", err)
+	}
+	if prepared.Attrs["qty"] != "3" {
+		t.Errorf("defaults stage did not fill qty: %q", prepared.Attrs["qty"])
+	}
+	if prepared.Attrs["linetotal"] != "120" {
+		t.Errorf("derive stage gave linetotal %q, want 120", prepared.Attrs["linetotal"])
+	}
+	if prepared.Attrs["warehouse"] != "pune" {
+		t.Errorf("enrich stage did not add warehouse: %q", prepared.Attrs["warehouse"])
+	}
+	if _, ok := entity.Attrs["linetotal"]; ok {
+		t.Errorf("pipeline mutated the caller's entity")
+	}
+
+	schema.Pipeline = []string{"scramble"}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Fatalf("unknown pipeline stage accepted at save")
+	}
+	schema.Pipeline = []string{"enrich:nosuchfunc"}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Fatalf("pipeline with unregistered enrichment accepted at save")
+	}
+}
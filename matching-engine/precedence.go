@@ -0,0 +1,79 @@
+package crux
+
+import "fmt"
+
+// AttrPrecedence selects which source wins when a request value, a
+// caller override and a schema default could all supply the same
+// attribute during entity building.
+type AttrPrecedence int
+
+const (
+	// PrecedenceOverride lets overrides beat request values, which beat
+	// schema defaults. This is the default: an override exists precisely
+	// to displace what the request carried.
+	PrecedenceOverride AttrPrecedence = iota
+	// PrecedenceRequest lets request values beat overrides; defaults
+	// still fill only what both leave unset.
+	PrecedenceRequest
+	// PrecedenceDefault lets schema defaults beat both, pinning
+	// attributes to their declared values regardless of input.
+	PrecedenceDefault
+)
+
+// attrPrecedence is the policy entity building applies between supplied
+// attribute values and schema defaults; overrides only exist for
+// callers going through ResolveEntityAttrs.
+var attrPrecedence = PrecedenceOverride
+
+// SetAttrPrecedence sets the precedence policy entity building applies
+// and returns the previous one.
+func SetAttrPrecedence(policy AttrPrecedence) AttrPrecedence {
+	prev := attrPrecedence
+	attrPrecedence = policy
+	return prev
+}
+
+// ResolveEntityAttrs builds the attribute map an evaluation sees for a
+// class from the request's values, the caller's overrides and the
+// schema's defaults, under the given precedence policy. Callers
+// assembling entities from several sources resolve them here before
+// verification.
+func ResolveEntityAttrs(app, class string, request, overrides map[string]string, policy AttrPrecedence) (map[string]string, error) {
+	schema := scopedRuleSchema(app, class)
+	if schema == nil {
+		return nil, fmt.Errorf("no schema found for class %q", class)
+	}
+	return resolveEntityAttrs(schema, request, overrides, policy), nil
+}
+
+// resolveEntityAttrs builds the attribute map an evaluation sees from
+// the request's values, the caller's overrides and the schema's
+// defaults, applying one precedence policy across every attribute.
+func resolveEntityAttrs(schema *RuleSchema, request, overrides map[string]string, policy AttrPrecedence) map[string]string {
+	attrs := map[string]string{}
+	for k, v := range request {
+		attrs[k] = v
+	}
+	switch policy {
+	case PrecedenceRequest:
+		for k, v := range overrides {
+			if _, ok := attrs[k]; !ok {
+				attrs[k] = v
+			}
+		}
+	default:
+		for k, v := range overrides {
+			attrs[k] = v
+		}
+	}
+	for i := range schema.PatternSchema {
+		as := &schema.PatternSchema[i]
+		if as.Default == "" {
+			continue
+		}
+		if _, ok := attrs[as.Name]; !ok || policy == PrecedenceDefault {
+			attrs[as.Name] = as.Default
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,70 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+// The same request, overrides and schema default must resolve to a
+// different winner under each precedence policy.
+func TestAttrPrecedencePolicies(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema[0].Default = "notebook" // cat
+	teardown := setupTestSchema(t, schema, nil)
+	defer teardown()
+	request := map[string]string{"cat": "textbook", "mrp": "50"}
+	overrides := map[string]string{"cat": "magazine"}
+
+	cases := []struct {
+		policy AttrPrecedence
+		want   string
+	}{
+		{PrecedenceOverride, "magazine"},
+		{PrecedenceRequest, "textbook"},
+		{PrecedenceDefault, "notebook"},
+	}
+	for _, tc := range cases {
+		attrs, err := ResolveEntityAttrs("", "inventoryitems", request, overrides, tc.policy)
+		if err != nil {
+			t.Fatalf("ResolveEntityAttrs(policy %d): %v", tc.policy, err)
+		}
+		if attrs["cat"] != tc.want {
+			t.Errorf("policy %d: cat = %q, want %q", tc.policy, attrs["cat"], tc.want)
+		}
+		if attrs["mrp"] != "50" {
+			t.Errorf("policy %d: uncontested request value lost: mrp = %q", tc.policy, attrs["mrp"])
+		}
+	}
+
+	// A default only fills attributes nothing else supplied, except under
+	// PrecedenceDefault where it always wins.
+	attrs, err := ResolveEntityAttrs("", "inventoryitems", map[string]string{}, nil, PrecedenceOverride)
+	if err != nil {
+		t.Fatalf("ResolveEntityAttrs: %v", err)
+	}
+	if attrs["cat"] != "notebook" {
+		t.Errorf("default did not fill unset attribute: cat = %q", attrs["cat"])
+	}
+
+	// The engine-wide policy reaches evaluation: under PrecedenceDefault
+	// the declared default displaces the supplied value.
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "precedenceset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	ruleSets[rs.SetName] = rs
+	prev := SetAttrPrecedence(PrecedenceDefault)
+	defer SetAttrPrecedence(prev)
+	actionSet, err := Match(context.Background(), "precedenceset", inventoryEntity())
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("pinned default did not reach matching: %+v", actionSet)
+	}
+}
@@ -0,0 +1,157 @@
+package crux
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// engineVersion identifies the engine build a receipt was issued by.
+const engineVersion = "crux-1"
+
+// DecisionReceipt is a self-contained, signed record of one evaluation:
+// what entity was decided (by fingerprint), against which ruleset and
+// schema version, which rules matched, what came out, and when. The
+// HMAC signature lets an auditor verify the receipt was issued by a
+// holder of the realm's receipt key and has not been altered since.
+type DecisionReceipt struct {
+	EntityFP      uint64            `json:"entityfp"`
+	SetName       string            `json:"setname"`
+	RuleSetID     int               `json:"rulesetid"`
+	SchemaVer     int               `json:"schemaver"`
+	EngineVersion string            `json:"engineversion"`
+	MatchedRules  []string          `json:"matchedrules"` // "setname#ruleidx", in match order
+	Tasks         []string          `json:"tasks"`
+	Properties    map[string]string `json:"properties"`
+	IssuedAt      time.Time         `json:"issuedat"`
+	Signature     string            `json:"signature"`
+}
+
+// receiptKey signs decision receipts. Empty disables signing: receipts
+// are still issued but carry no signature and cannot be verified.
+var receiptKey []byte
+
+// SetReceiptKey installs the HMAC key used to sign decision receipts.
+func SetReceiptKey(key []byte) {
+	receiptKey = append([]byte(nil), key...)
+}
+
+// receiptStore keeps the most recent receipts when persistence is
+// enabled, oldest first.
+var receiptStore = struct {
+	mu       sync.Mutex
+	max      int
+	receipts []DecisionReceipt
+}{}
+
+// EnableReceiptStore keeps the last max issued receipts in memory for
+// later audit retrieval; 0 disables the store.
+func EnableReceiptStore(max int) {
+	receiptStore.mu.Lock()
+	defer receiptStore.mu.Unlock()
+	receiptStore.max = max
+	receiptStore.receipts = nil
+}
+
+// StoredReceipts returns a copy of the retained receipts, oldest first.
+func StoredReceipts() []DecisionReceipt {
+	receiptStore.mu.Lock()
+	defer receiptStore.mu.Unlock()
+	return append([]DecisionReceipt(nil), receiptStore.receipts...)
+}
+
+// MatchWithReceipt evaluates like MatchWithOptions and additionally
+// issues a signed decision receipt for the evaluation, giving callers
+// that must prove a decision an exported entry point.
+func MatchWithReceipt(ctx context.Context, setName string, entity Entity, opts MatchOptions) (ActionSet, DecisionReceipt, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return ActionSet{}, DecisionReceipt{}, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return ActionSet{}, DecisionReceipt{}, err
+	}
+	actionSet, _, receipt, err := doMatchWithReceipt(ctx, entity, rs,
+		ActionSet{Properties: map[string]string{}}, map[string]struct{}{}, opts.config())
+	return actionSet, receipt, err
+}
+
+// doMatchWithReceipt evaluates like doMatchContext and additionally
+// issues a signed decision receipt for the evaluation.
+func doMatchWithReceipt(ctx context.Context, entity Entity, ruleSet *RuleSet, actionSet ActionSet, seenRuleSets map[string]struct{}, cfg matchConfig) (ActionSet, bool, DecisionReceipt, error) {
+	ec := &evalCtx{ctx: ctx, cfg: cfg}
+	res, keepGoing, err := ec.run(entity, ruleSet, actionSet, seenRuleSets)
+	if err != nil {
+		return res, keepGoing, DecisionReceipt{}, err
+	}
+	receipt := DecisionReceipt{
+		EntityFP:      entityFingerprint(entity),
+		SetName:       ruleSet.SetName,
+		RuleSetID:     ruleSet.Id,
+		EngineVersion: engineVersion,
+		MatchedRules:  ec.matchedRules,
+		Tasks:         res.Tasks,
+		Properties:    res.Properties,
+		IssuedAt:      time.Now().UTC(),
+	}
+	if schema := schemaFor(ruleSet); schema != nil {
+		receipt.SchemaVer = schema.Version
+	}
+	receipt.Signature = signReceipt(&receipt)
+	retainReceipt(receipt)
+	return res, keepGoing, receipt, nil
+}
+
+// retainReceipt appends to the receipt store if persistence is enabled.
+func retainReceipt(r DecisionReceipt) {
+	receiptStore.mu.Lock()
+	defer receiptStore.mu.Unlock()
+	if receiptStore.max <= 0 {
+		return
+	}
+	receiptStore.receipts = append(receiptStore.receipts, r)
+	if len(receiptStore.receipts) > receiptStore.max {
+		receiptStore.receipts = receiptStore.receipts[1:]
+	}
+}
+
+// signReceipt computes the receipt's HMAC-SHA256 signature over its
+// canonical JSON form with the signature field blanked. Returns "" when
+// no key is installed.
+func signReceipt(r *DecisionReceipt) string {
+	if len(receiptKey) == 0 {
+		return ""
+	}
+	unsigned := *r
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, receiptKey)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyReceipt recomputes the receipt's signature and reports whether
+// it matches, which proves the receipt is intact and was signed with the
+// installed key.
+func VerifyReceipt(r DecisionReceipt) error {
+	if len(receiptKey) == 0 {
+		return fmt.Errorf("no receipt key installed")
+	}
+	if r.Signature == "" {
+		return fmt.Errorf("receipt is unsigned")
+	}
+	want := signReceipt(&r)
+	if !hmac.Equal([]byte(want), []byte(r.Signature)) {
+		return fmt.Errorf("receipt signature mismatch")
+	}
+	return nil
+}
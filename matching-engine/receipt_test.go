@@ -0,0 +1,56 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+// An issued receipt records the fingerprint, ruleset, matched rules and
+// outcome, verifies against the installed key, and fails verification
+// once any field is tampered with.
+func TestDecisionReceipt(t *testing.T) {
+	SetReceiptKey([]byte("audit-key"))
+	defer SetReceiptKey(nil)
+	EnableReceiptStore(10)
+	defer EnableReceiptStore(0)
+
+	rs := &RuleSet{
+		Id:      7,
+		Class:   "inventoryitems",
+		SetName: "receiptset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	_, receipt, err := MatchWithReceipt(context.Background(), "receiptset", inventoryEntity(), MatchOptions{})
+	if err != nil {
+		t.Fatalf("MatchWithReceipt: %v", err)
+	}
+	if receipt.SetName != "receiptset" || receipt.RuleSetID != 7 || receipt.EngineVersion != engineVersion {
+		t.Errorf("receipt identity wrong: %+v", receipt)
+	}
+	if len(receipt.MatchedRules) != 1 || receipt.MatchedRules[0] != "receiptset#0" {
+		t.Errorf("matched rules not recorded: %v", receipt.MatchedRules)
+	}
+	if receipt.EntityFP == 0 || receipt.IssuedAt.IsZero() {
+		t.Errorf("fingerprint or timestamp missing: %+v", receipt)
+	}
+	if err := VerifyReceipt(receipt); err != nil {
+		t.Fatalf("VerifyReceipt: %v", err)
+	}
+
+	tampered := receipt
+	tampered.Tasks = []string{"refund"}
+	if err := VerifyReceipt(tampered); err == nil {
+		t.Fatalf("tampered receipt verified")
+	}
+
+	stored := StoredReceipts()
+	if len(stored) != 1 || stored[0].Signature != receipt.Signature {
+		t.Errorf("receipt not retained in the store: %v", stored)
+	}
+}
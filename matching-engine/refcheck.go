@@ -0,0 +1,76 @@
+package crux
+
+// DanglingRef reports one reference that does not resolve: a
+// THENCALL/ELSECALL naming no ruleset or crossing an app boundary, or a
+// nextstep naming no declared workflow step.
+type DanglingRef struct {
+	SetName string `json:"setname"`
+	RuleIdx int    `json:"rule"`
+	Kind    string `json:"kind"` // "thencall", "elsecall", "crossapp" or "nextstep"
+	Target  string `json:"target"`
+}
+
+// DoReferentialChecks verifies that every THENCALL/ELSECALL in the
+// candidate rulesets resolves, either to another candidate or to a
+// ruleset already loaded, and reports every dangling reference across
+// the combined graph. Import flows run this before persisting a batch of
+// interdependent rulesets.
+func DoReferentialChecks(candidates []*RuleSet) []DanglingRef {
+	apps := map[string]string{}
+	for name, rs := range ruleSets {
+		apps[name] = rs.App
+	}
+	for _, rs := range candidates {
+		apps[rs.SetName] = rs.App
+	}
+	var dangling []DanglingRef
+	for _, rs := range candidates {
+		for i := range rs.Rules {
+			ra := &rs.Rules[i].RuleActions
+			if ra.ThenCall != "" {
+				dangling = append(dangling, checkCallRef(rs, i, "thencall", ra.ThenCall, apps)...)
+			}
+			if ra.ElseCall != "" {
+				dangling = append(dangling, checkCallRef(rs, i, "elsecall", ra.ElseCall, apps)...)
+			}
+		}
+		dangling = append(dangling, danglingNextSteps(rs)...)
+	}
+	return dangling
+}
+
+// checkCallRef resolves one THENCALL/ELSECALL target: it must exist,
+// and it must not belong to a different app — a shared (app-less)
+// ruleset may be called from anywhere.
+func checkCallRef(rs *RuleSet, ruleIdx int, kind, target string, apps map[string]string) []DanglingRef {
+	targetApp, ok := apps[target]
+	if !ok {
+		return []DanglingRef{{rs.SetName, ruleIdx, kind, target}}
+	}
+	if targetApp != "" && targetApp != rs.App {
+		return []DanglingRef{{rs.SetName, ruleIdx, "crossapp", target}}
+	}
+	return nil
+}
+
+// danglingNextSteps reports every nextstep a workflow ruleset's rules
+// set to a value outside the step attribute's enum — a route to a step
+// that does not exist. Rulesets whose class has no step enum (plain
+// business-rule classes) are skipped.
+func danglingNextSteps(rs *RuleSet) []DanglingRef {
+	stepSchema := getAttrSchema(schemaFor(rs), stepAttr)
+	if stepSchema == nil || stepSchema.ValType != typeEnum {
+		return nil
+	}
+	var dangling []DanglingRef
+	for i := range rs.Rules {
+		next, ok := rs.Rules[i].RuleActions.Properties[nextStepProp]
+		if !ok {
+			continue
+		}
+		if _, declared := stepSchema.Vals[next]; !declared {
+			dangling = append(dangling, DanglingRef{rs.SetName, i, "nextstep", next})
+		}
+	}
+	return dangling
+}
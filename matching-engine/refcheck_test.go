@@ -0,0 +1,55 @@
+package crux
+
+import "testing"
+
+func TestDoReferentialChecks(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	candidates := []*RuleSet{
+		{
+			Class: "inventoryitems", SetName: "alpha",
+			Rules: []Rule{{RuleActions: RuleActions{ThenCall: "beta"}}},
+		},
+		{
+			Class: "inventoryitems", SetName: "beta",
+			Rules: []Rule{
+				{RuleActions: RuleActions{ElseCall: "alpha"}},
+				{RuleActions: RuleActions{ThenCall: "gamma"}},
+			},
+		},
+	}
+	dangling := DoReferentialChecks(candidates)
+	if len(dangling) != 1 {
+		t.Fatalf("dangling = %v, want exactly the gamma reference", dangling)
+	}
+	d := dangling[0]
+	if d.SetName != "beta" || d.RuleIdx != 1 || d.Kind != "thencall" || d.Target != "gamma" {
+		t.Errorf("unexpected dangling ref: %+v", d)
+	}
+
+	// A reference resolving to an already-loaded ruleset is not dangling.
+	ruleSets["gamma"] = &RuleSet{Class: "inventoryitems", SetName: "gamma"}
+	if dangling := DoReferentialChecks(candidates); len(dangling) != 0 {
+		t.Errorf("dangling = %v after loading gamma, want none", dangling)
+	}
+}
+
+// A workflow rule routing nextstep to a value outside the step enum is
+// reported with the ruleset name and the invalid step.
+func TestDoReferentialChecksNextStep(t *testing.T) {
+	defer setupTestSchema(t, wfOrderSchema(), nil)()
+	candidates := []*RuleSet{{
+		Class: "order", SetName: "orderflow",
+		Rules: []Rule{
+			{RuleActions: RuleActions{Properties: map[string]string{nextStepProp: "approve"}}},
+			{RuleActions: RuleActions{Properties: map[string]string{nextStepProp: "pack"}}},
+		},
+	}}
+	dangling := DoReferentialChecks(candidates)
+	if len(dangling) != 1 {
+		t.Fatalf("dangling = %v, want exactly the pack reference", dangling)
+	}
+	d := dangling[0]
+	if d.SetName != "orderflow" || d.RuleIdx != 1 || d.Kind != "nextstep" || d.Target != "pack" {
+		t.Errorf("unexpected dangling ref: %+v", d)
+	}
+}
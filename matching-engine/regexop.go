@@ -0,0 +1,76 @@
+package crux
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// opMATCH tests a string attribute against a regular expression given as
+// the term value. Patterns are RE2, so matching is linear in the input;
+// the input-size budget below bounds the constant.
+const opMATCH = "match"
+
+// defaultMaxRegexInput bounds the length of a value matched against a
+// regex term when no explicit budget is set. Rule inputs this large are
+// not attribute values, they are payloads.
+const defaultMaxRegexInput = 64 * 1024
+
+// maxRegexInput is the engine-wide budget on regex term inputs; 0
+// disables the check.
+var maxRegexInput = defaultMaxRegexInput
+
+// SetMaxRegexInput changes the size budget for values matched by regex
+// terms, returning the previous budget. Pass 0 to disable the check.
+func SetMaxRegexInput(limit int) int {
+	prev := maxRegexInput
+	maxRegexInput = limit
+	return prev
+}
+
+// regexCache holds compiled regex term patterns, filled at verification
+// so evaluation never recompiles.
+var regexCache = map[string]*regexp.Regexp{}
+
+// compileRegexTerm returns the compiled pattern for a regex term value,
+// compiling and caching it on first use.
+func compileRegexTerm(val any) (*regexp.Regexp, error) {
+	pattern, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("regex term needs a string pattern")
+	}
+	if re, ok := regexCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern %q: %v", pattern, err)
+	}
+	regexCache[pattern] = re
+	return re, nil
+}
+
+// verifyRegexTerm checks a regex term at save: only string-typed
+// attributes may be matched, and the pattern must compile.
+func verifyRegexTerm(rs *RuleSet, ruleIdx int, term *RulePatternTerm, as *AttrSchema) error {
+	if as != nil && as.ValType != typeStr {
+		return fmt.Errorf("ruleset %q rule %d: operator %q not valid for %s attribute %q", rs.SetName, ruleIdx, opMATCH, as.ValType, term.Attr)
+	}
+	if _, err := compileRegexTerm(term.Val); err != nil {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: %v", rs.SetName, ruleIdx, term.Attr, err)
+	}
+	return nil
+}
+
+// evalRegexTerm applies a regex term to the entity value, enforcing the
+// input-size budget first so a pathological payload fails fast instead
+// of burning the evaluation's latency budget.
+func evalRegexTerm(entityVal string, term *RulePatternTerm) (bool, error) {
+	if maxRegexInput > 0 && len(entityVal) > maxRegexInput {
+		return false, fmt.Errorf("value of %d bytes exceeds the regex input budget of %d", len(entityVal), maxRegexInput)
+	}
+	re, err := compileRegexTerm(term.Val)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(entityVal), nil
+}
@@ -0,0 +1,52 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+// A regex term matches string attributes; an input beyond the size
+// budget aborts with the budget error, and bad patterns or non-string
+// attributes are rejected at save.
+func TestRegexTermBudget(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "sku", ValType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "regexset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "sku", Op: opMATCH, Val: "^bk-[0-9]+$"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	entity := inventoryEntity()
+	entity.Attrs["sku"] = "bk-1234"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("regex term did not match: %+v", actionSet)
+	}
+
+	prev := SetMaxRegexInput(128)
+	defer SetMaxRegexInput(prev)
+	entity.Attrs["sku"] = strings.Repeat("x", 256)
+	_, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err == nil || !strings.Contains(err.Error(), "regex input budget") {
+		t.Fatalf("oversized input did not trigger the budget error: %v", err)
+	}
+
+	rs.Rules[0].RulePatterns[0].Val = "([unclosed"
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("invalid regex pattern accepted at save")
+	}
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "mrp", Op: opMATCH, Val: "^5"}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("regex term on a numeric attribute accepted at save")
+	}
+}
@@ -0,0 +1,51 @@
+package crux
+
+import "fmt"
+
+// DeactivateSchema marks a loaded schema version inactive, so
+// evaluation stops picking it without losing the definition — the way
+// drafts are parked next to live schemas. Deactivation is refused while
+// any loaded ruleset still evaluates against that version, naming the
+// rulesets in the error.
+func DeactivateSchema(class string, version int) error {
+	schema := getRuleSchemaVer(class, version)
+	if schema == nil {
+		return fmt.Errorf("no schema loaded for class %q version %d", class, version)
+	}
+	if schema.Inactive {
+		return nil
+	}
+	var holders []string
+	for name, rs := range ruleSets {
+		if schemaFor(rs) == schema {
+			holders = append(holders, name)
+		}
+	}
+	if len(holders) > 0 {
+		return fmt.Errorf("schema for class %q version %d is still evaluated by rulesets %v", class, version, holders)
+	}
+	schema.Inactive = true
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: class, Version: version})
+	return nil
+}
+
+// ActivateSchema brings a deactivated schema version back. The schema
+// is re-verified first, since what it must agree with may have changed
+// while it was parked.
+func ActivateSchema(class string, version int, isWF bool) error {
+	schema := getRuleSchemaVer(class, version)
+	if schema == nil {
+		return fmt.Errorf("no schema loaded for class %q version %d", class, version)
+	}
+	if !schema.Inactive {
+		return nil
+	}
+	if err := verifyRuleSchema(schema, isWF); err != nil {
+		return fmt.Errorf("cannot reactivate schema for class %q version %d: %v", class, version, err)
+	}
+	schema.Inactive = false
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: class, Version: version})
+	return nil
+}
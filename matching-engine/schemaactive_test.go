@@ -0,0 +1,37 @@
+package crux
+
+import "testing"
+
+// A deactivated schema stops being picked for evaluation; deactivation
+// is refused while rulesets still evaluate against it, and reactivation
+// re-verifies the schema.
+func TestSchemaActivation(t *testing.T) {
+	schema := inventorySchema()
+	rs := &RuleSet{Id: 1, Class: "inventoryitems", SetName: "activeset"}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	if err := DeactivateSchema("inventoryitems", 0); err == nil {
+		t.Fatalf("deactivation accepted while a ruleset references the schema")
+	}
+	delete(ruleSets, "activeset")
+	if err := DeactivateSchema("inventoryitems", 0); err != nil {
+		t.Fatalf("DeactivateSchema: %v", err)
+	}
+	if getRuleSchema("inventoryitems") != nil {
+		t.Errorf("inactive schema still picked for evaluation")
+	}
+
+	// Reactivation re-verifies; break the parked schema first.
+	schema.PatternSchema[0].ValType = "nosuchtype"
+	if err := ActivateSchema("inventoryitems", 0, false); err == nil {
+		t.Fatalf("broken schema reactivated without verification")
+	}
+	schema.PatternSchema[0].ValType = typeEnum
+	if err := ActivateSchema("inventoryitems", 0, false); err != nil {
+		t.Fatalf("ActivateSchema: %v", err)
+	}
+	if getRuleSchema("inventoryitems") != schema {
+		t.Errorf("reactivated schema not picked for evaluation")
+	}
+}
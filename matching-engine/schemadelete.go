@@ -0,0 +1,50 @@
+package crux
+
+import (
+	"fmt"
+	"time"
+)
+
+// DeleteSchema soft-deletes a loaded schema version: it gets a
+// tombstone timestamp and disappears from lookups and listings, but the
+// definition survives for RestoreSchema. Deletion is refused while any
+// loaded ruleset is of the schema's class, naming the rulesets — the
+// hard-delete this replaces bit exactly that way.
+func DeleteSchema(class string, version int) error {
+	schema := getRuleSchemaVer(class, version)
+	if schema == nil {
+		return fmt.Errorf("no schema loaded for class %q version %d", class, version)
+	}
+	if !schema.DeletedAt.IsZero() {
+		return nil
+	}
+	var holders []string
+	for name, rs := range ruleSets {
+		if rs.Class == class {
+			holders = append(holders, name)
+		}
+	}
+	if len(holders) > 0 {
+		return fmt.Errorf("schema for class %q is still referenced by rulesets %v", class, holders)
+	}
+	schema.DeletedAt = time.Now().UTC()
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: class, Version: version})
+	return nil
+}
+
+// RestoreSchema clears a soft-deleted schema's tombstone, bringing it
+// back into lookups and listings.
+func RestoreSchema(class string, version int) error {
+	schema := getRuleSchemaVer(class, version)
+	if schema == nil {
+		return fmt.Errorf("no schema loaded for class %q version %d", class, version)
+	}
+	if schema.DeletedAt.IsZero() {
+		return nil
+	}
+	schema.DeletedAt = time.Time{}
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: class, Version: version})
+	return nil
+}
@@ -0,0 +1,38 @@
+package crux
+
+import "testing"
+
+// A soft-deleted schema disappears from lookups and default listings
+// but survives for restore; deletion is refused while rulesets of the
+// class are loaded.
+func TestSchemaSoftDelete(t *testing.T) {
+	schema := inventorySchema()
+	rs := &RuleSet{Id: 1, Class: "inventoryitems", SetName: "delset"}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	if err := DeleteSchema("inventoryitems", 0); err == nil {
+		t.Fatalf("deletion accepted while a ruleset references the class")
+	}
+	delete(ruleSets, "delset")
+	if err := DeleteSchema("inventoryitems", 0); err != nil {
+		t.Fatalf("DeleteSchema: %v", err)
+	}
+	if _, ok := GetSchema("inventoryitems"); ok {
+		t.Errorf("deleted schema still returned by GetSchema")
+	}
+	if _, total := ListSchemas("", "", 0, 0, false); total != 0 {
+		t.Errorf("deleted schema still counted in default listing")
+	}
+	page, total := ListSchemas("", "", 0, 0, true)
+	if total != 1 || len(page) != 1 || !page[0].Deleted {
+		t.Errorf("includeDeleted listing wrong: total=%d page=%+v", total, page)
+	}
+
+	if err := RestoreSchema("inventoryitems", 0); err != nil {
+		t.Fatalf("RestoreSchema: %v", err)
+	}
+	if got, ok := GetSchema("inventoryitems"); !ok || got != schema {
+		t.Errorf("restored schema not returned by GetSchema")
+	}
+}
@@ -0,0 +1,69 @@
+package crux
+
+import "sync"
+
+// schemaIndex caches the schema lookups that would otherwise scan the
+// global slices on every evaluation: the active schema per class, and
+// each schema's attributes by name. It is built lazily on first lookup
+// and dropped whenever the loaded schemas change, so readers never see
+// a stale entry.
+var schemaIndex = struct {
+	mu      sync.Mutex
+	byClass map[[2]string]*RuleSchema // keyed by (app, class)
+	attrs   map[*RuleSchema]map[string]*AttrSchema
+}{}
+
+// invalidateSchemaIndex drops the index; the next lookup rebuilds it.
+// Every path that mutates ruleSchemas must call it.
+func invalidateSchemaIndex() {
+	schemaIndex.mu.Lock()
+	defer schemaIndex.mu.Unlock()
+	schemaIndex.byClass = nil
+	schemaIndex.attrs = nil
+}
+
+// indexedSchema returns the active schema for an (app, class) pair from
+// the index, building the index from ruleSchemas when it is absent. An
+// empty app is the realm-shared scope.
+func indexedSchema(app, class string) *RuleSchema {
+	schemaIndex.mu.Lock()
+	defer schemaIndex.mu.Unlock()
+	if schemaIndex.byClass == nil {
+		schemaIndex.byClass = map[[2]string]*RuleSchema{}
+		for _, rs := range ruleSchemas {
+			if rs.Inactive || !rs.DeletedAt.IsZero() {
+				continue
+			}
+			key := [2]string{rs.App, rs.Class}
+			active := schemaIndex.byClass[key]
+			if active == nil || rs.Version > active.Version {
+				schemaIndex.byClass[key] = rs
+			}
+		}
+	}
+	return schemaIndex.byClass[[2]string{app, class}]
+}
+
+// indexedAttr returns the named attribute's schema from the per-schema
+// attribute index, building that schema's map on first use. Ad hoc
+// schemas that never get saved are indexed too; invalidation drops them
+// along with the rest.
+func indexedAttr(rs *RuleSchema, name string) *AttrSchema {
+	schemaIndex.mu.Lock()
+	defer schemaIndex.mu.Unlock()
+	if schemaIndex.attrs == nil {
+		schemaIndex.attrs = map[*RuleSchema]map[string]*AttrSchema{}
+	}
+	byName, ok := schemaIndex.attrs[rs]
+	if !ok {
+		byName = make(map[string]*AttrSchema, len(rs.PatternSchema))
+		for i := range rs.PatternSchema {
+			as := &rs.PatternSchema[i]
+			if _, dup := byName[as.Name]; !dup {
+				byName[as.Name] = as
+			}
+		}
+		schemaIndex.attrs[rs] = byName
+	}
+	return byName[name]
+}
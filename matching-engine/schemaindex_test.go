@@ -0,0 +1,57 @@
+package crux
+
+import "testing"
+
+// Schema lookups go through the class index, and saving a newer version
+// invalidates it so the next lookup sees the new active schema.
+func TestSchemaIndexInvalidation(t *testing.T) {
+	schema := inventorySchema()
+	teardown := setupTestSchema(t, schema, nil)
+	defer teardown()
+
+	if got := getRuleSchema("inventoryitems"); got != schema {
+		t.Fatalf("index did not resolve the loaded schema")
+	}
+	if as := getAttrSchema(schema, "mrp"); as == nil || as.ValType != typeFloat {
+		t.Fatalf("attribute index did not resolve mrp: %+v", as)
+	}
+	if as := getAttrSchema(schema, "nosuchattr"); as != nil {
+		t.Fatalf("attribute index resolved an undeclared attribute")
+	}
+
+	v2 := inventorySchema()
+	v2.Version = 2
+	if err := SaveRuleSchema(v2, false); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+	if got := getRuleSchema("inventoryitems"); got != v2 {
+		t.Errorf("index not invalidated by save; active schema is stale")
+	}
+}
+
+// ListSchemas pages deterministically by class and version and applies
+// the class substring filter before counting.
+func TestListSchemas(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+	order := wfOrderSchema()
+	if err := SaveRuleSchema(order, true); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+
+	page, total := ListSchemas("", "", 1, 0, false)
+	if total != 2 || len(page) != 1 || page[0].Class != "inventoryitems" {
+		t.Fatalf("unexpected first page: total=%d page=%+v", total, page)
+	}
+	page, total = ListSchemas("", "", 1, 1, false)
+	if total != 2 || len(page) != 1 || page[0].Class != "order" {
+		t.Fatalf("unexpected second page: total=%d page=%+v", total, page)
+	}
+	page, total = ListSchemas("", "inventory", 0, 0, false)
+	if total != 1 || len(page) != 1 || page[0].NAttrs != len(inventorySchema().PatternSchema) {
+		t.Fatalf("unexpected filtered page: total=%d page=%+v", total, page)
+	}
+	if page, total = ListSchemas("", "", 10, 5, false); total != 2 || len(page) != 0 {
+		t.Errorf("offset past the end should yield an empty page, got %+v", page)
+	}
+}
@@ -0,0 +1,34 @@
+package crux
+
+// UnknownClassPolicy decides what the engine does when a ruleset's class
+// has no schema at evaluation time.
+type UnknownClassPolicy int
+
+const (
+	// UnknownClassError fails the evaluation, the default: matching
+	// without a schema forfeits typing, canonicalization and the
+	// pipeline, which production realms should never do silently.
+	UnknownClassError UnknownClassPolicy = iota
+	// UnknownClassPassthrough evaluates schema-less: every attribute is
+	// an untyped string, and only the EQ and NE operators are legal.
+	// Meant for prototyping before a schema is formalized.
+	UnknownClassPassthrough
+)
+
+// unknownClassPolicy is the engine-wide policy for classes without a
+// schema.
+var unknownClassPolicy = UnknownClassError
+
+// SetUnknownClassPolicy sets the policy applied when a class has no
+// schema, returning the previous one.
+func SetUnknownClassPolicy(p UnknownClassPolicy) UnknownClassPolicy {
+	prev := unknownClassPolicy
+	unknownClassPolicy = p
+	return prev
+}
+
+// schemalessOpAllowed reports whether an operator is legal without a
+// schema: with no type information only string identity is meaningful.
+func schemalessOpAllowed(op string) bool {
+	return op == opEQ || op == opNE
+}
@@ -0,0 +1,54 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+// Under UnknownClassPassthrough a class with no schema evaluates with
+// plain string EQ/NE matching; ordering operators are rejected, and the
+// default policy still errors.
+func TestSchemalessPassthrough(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "prototype",
+		SetName: "protoset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "color", Op: opEQ, Val: "red"}},
+			RuleActions:  RuleActions{Tasks: []string{"paint"}},
+		}},
+	}
+	entity := Entity{Class: "prototype", Attrs: map[string]string{"color": "red"}}
+
+	if _, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}); err == nil {
+		t.Fatalf("schema-less evaluation succeeded under the default policy")
+	}
+
+	prev := SetUnknownClassPolicy(UnknownClassPassthrough)
+	defer SetUnknownClassPolicy(prev)
+
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 || actionSet.Tasks[0] != "paint" {
+		t.Errorf("schema-less EQ rule did not fire: %+v", actionSet)
+	}
+
+	// The exported path honors the policy too: entity verification
+	// must not reject the class before evaluation gets a say.
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	actionSet, err = Match(context.Background(), "protoset", entity)
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 || actionSet.Tasks[0] != "paint" {
+		t.Errorf("schema-less EQ rule did not fire via Match: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "color", Op: opGT, Val: "blue"}
+	if _, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{}); err == nil {
+		t.Fatalf("ordering operator accepted in schema-less mode")
+	}
+}
@@ -0,0 +1,71 @@
+package crux
+
+import (
+	"sort"
+	"strings"
+)
+
+// SchemaListing is one loaded schema in a list response: its identity
+// and enough shape to render an index, without the full definition.
+type SchemaListing struct {
+	App         string `json:"app,omitempty"`
+	Class       string `json:"class"`
+	Description string `json:"description,omitempty"`
+	Version     int    `json:"version"`
+	NAttrs      int    `json:"nattrs"`
+	NTasks      int    `json:"ntasks"`
+	Deleted     bool   `json:"deleted,omitempty"`
+}
+
+// ListSchemas returns one page of the loaded schemas, ordered by class
+// then version, plus the total count matching the filter. appFilter,
+// when non-empty, keeps only that app's schemas plus the realm-shared
+// ones; classFilter keeps only classes containing it as a substring;
+// soft-deleted schemas appear only when includeDeleted is set. The
+// paging happens here rather than in the caller so every consumer pages
+// the same way.
+func ListSchemas(appFilter, classFilter string, limit, offset int, includeDeleted bool) ([]SchemaListing, int) {
+	var matched []*RuleSchema
+	for _, rs := range ruleSchemas {
+		if appFilter != "" && rs.App != "" && rs.App != appFilter {
+			continue
+		}
+		if classFilter != "" && !strings.Contains(rs.Class, classFilter) {
+			continue
+		}
+		if !includeDeleted && !rs.DeletedAt.IsZero() {
+			continue
+		}
+		matched = append(matched, rs)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Class != matched[j].Class {
+			return matched[i].Class < matched[j].Class
+		}
+		return matched[i].Version < matched[j].Version
+	})
+	total := len(matched)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	page := make([]SchemaListing, 0, end-offset)
+	for _, rs := range matched[offset:end] {
+		page = append(page, SchemaListing{
+			App:         rs.App,
+			Class:       rs.Class,
+			Description: rs.Description,
+			Version:     rs.Version,
+			NAttrs:      len(rs.PatternSchema),
+			NTasks:      len(rs.ActionSchema.Tasks),
+			Deleted:     !rs.DeletedAt.IsZero(),
+		})
+	}
+	return page, total
+}
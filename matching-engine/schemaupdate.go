@@ -0,0 +1,51 @@
+package crux
+
+import "fmt"
+
+// SchemaConflictError reports an optimistic-concurrency failure: the
+// schema was edited between the caller's read and their update. Callers
+// surface it as a conflict rather than a plain failure.
+type SchemaConflictError struct {
+	Class    string
+	Version  int
+	Expected int // revision the caller read
+	Actual   int // revision now loaded
+}
+
+func (e *SchemaConflictError) Error() string {
+	return fmt.Sprintf("schema for class %q version %d is at revision %d, not %d; reload and retry",
+		e.Class, e.Version, e.Actual, e.Expected)
+}
+
+// UpdateRuleSchema replaces a loaded schema version with an edited one,
+// but only when expectRevision matches the loaded revision — two
+// editors racing on the same schema cannot silently clobber each other.
+// The caller gets the revision from GetSchema and sends it back with
+// the edit; a successful update bumps it.
+func UpdateRuleSchema(schema *RuleSchema, isWF bool, expectRevision int) error {
+	existing := getRuleSchemaVer(schema.Class, schema.Version)
+	if existing == nil {
+		return fmt.Errorf("no schema loaded for class %q version %d", schema.Class, schema.Version)
+	}
+	if existing.Revision != expectRevision {
+		return &SchemaConflictError{
+			Class:    schema.Class,
+			Version:  schema.Version,
+			Expected: expectRevision,
+			Actual:   existing.Revision,
+		}
+	}
+	if err := verifyRuleSchema(schema, isWF); err != nil {
+		return err
+	}
+	schema.Revision = existing.Revision + 1
+	for i, rs := range ruleSchemas {
+		if rs == existing {
+			ruleSchemas[i] = schema
+			break
+		}
+	}
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: schema.Class, Version: schema.Version})
+	return nil
+}
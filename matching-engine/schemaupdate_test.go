@@ -0,0 +1,46 @@
+package crux
+
+import (
+	"errors"
+	"testing"
+)
+
+// An update carrying a stale revision is rejected as a conflict; one
+// carrying the current revision applies and bumps it.
+func TestSchemaUpdateConflict(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+	ruleSchemas = nil
+	invalidateSchemaIndex()
+
+	original := inventorySchema()
+	if err := SaveRuleSchema(original, false); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+	if original.Revision != 1 {
+		t.Fatalf("saved schema at revision %d, want 1", original.Revision)
+	}
+
+	edited := inventorySchema()
+	edited.Description = "first editor's change"
+	if err := UpdateRuleSchema(edited, false, 1); err != nil {
+		t.Fatalf("UpdateRuleSchema: %v", err)
+	}
+	if got, _ := GetSchema("inventoryitems"); got != edited || got.Revision != 2 {
+		t.Fatalf("update not applied: %+v", got)
+	}
+
+	stale := inventorySchema()
+	stale.Description = "second editor's change, from a stale read"
+	err := UpdateRuleSchema(stale, false, 1)
+	var conflict *SchemaConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("stale update did not conflict: %v", err)
+	}
+	if conflict.Actual != 2 || conflict.Expected != 1 {
+		t.Errorf("unexpected conflict detail: %+v", conflict)
+	}
+	if got, _ := GetSchema("inventoryitems"); got.Description != "first editor's change" {
+		t.Errorf("stale update clobbered the schema: %+v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package crux
+
+import "testing"
+
+// A ruleset pinned to schema v1 must keep verifying and evaluating
+// against v1 even after a v2 schema with different attributes becomes
+// the active version for the class.
+func TestPinnedSchemaVersionSurvivesActivation(t *testing.T) {
+	v1 := inventorySchema()
+	v1.Version = 1
+	rs := &RuleSet{
+		Id:              1,
+		Class:           "inventoryitems",
+		SetName:         "pinnedset",
+		PinnedSchemaVer: 1,
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 100.0}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, v1, rs)
+	defer teardown()
+
+	// v2 renames mrp away; an unpinned ruleset would now fail to verify.
+	v2 := &RuleSchema{
+		Class:   "inventoryitems",
+		Version: 2,
+		PatternSchema: []AttrSchema{
+			{Name: "listprice", ValType: typeFloat},
+		},
+		ActionSchema: v1.ActionSchema,
+	}
+	ruleSchemas = append(ruleSchemas, v2)
+	invalidateSchemaIndex()
+
+	if got := getRuleSchema("inventoryitems"); got != v2 {
+		t.Fatalf("active schema is not v2 after activation")
+	}
+	if got := schemaFor(rs); got != v1 {
+		t.Fatalf("pinned ruleset resolved schema version %d, want 1", got.Version)
+	}
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("pinned ruleset failed verification against its pinned schema: %v", err)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["mrp"] = "150"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 || actionSet.Tasks[0] != "invitefordiwali" {
+		t.Fatalf("pinned ruleset did not evaluate against its pinned schema: tasks %v", actionSet.Tasks)
+	}
+
+	if pinned := pinnedRuleSets("inventoryitems", 2); len(pinned) != 1 || pinned[0] != "pinnedset" {
+		t.Fatalf("pinnedRuleSets = %v, want [pinnedset]", pinned)
+	}
+}
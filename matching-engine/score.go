@@ -0,0 +1,64 @@
+package crux
+
+import "fmt"
+
+// ScoreContribution records how much one matched rule added to a scored
+// outcome, with the running total after it applied, so consumers can
+// present "top factors" behind a score.
+type ScoreContribution struct {
+	RuleIdx    int     `json:"ruleidx"`
+	Weight     float64 `json:"weight"`
+	Cumulative float64 `json:"cumulative"`
+}
+
+// ScoreResult is the outcome of a scoring evaluation: the total and the
+// per-rule breakdown that produced it.
+type ScoreResult struct {
+	Total         float64             `json:"total"`
+	Contributions []ScoreContribution `json:"contributions"`
+}
+
+// ScoreMatch evaluates a loaded ruleset in scoring mode, verifying the
+// entity first, and returns the total with its per-rule attribution
+// trail for downstream systems.
+func ScoreMatch(setName string, entity Entity) (ScoreResult, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return ScoreResult{}, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return ScoreResult{}, err
+	}
+	return scoreMatch(entity, rs)
+}
+
+// scoreMatch evaluates rs in scoring mode: every rule whose pattern
+// matches contributes its weight to the total, and the result carries
+// the full attribution trail. Call chains and EXIT/RETURN semantics do
+// not apply in scoring mode; each rule stands alone.
+func scoreMatch(entity Entity, rs *RuleSet) (ScoreResult, error) {
+	schema := schemaFor(rs)
+	if schema == nil {
+		return ScoreResult{}, fmt.Errorf("no schema found for class %q", rs.Class)
+	}
+	var result ScoreResult
+	actionSet := ActionSet{Properties: map[string]string{}}
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		matched, _, err := matchPattern(entity, rule.RulePatterns, actionSet, schema)
+		if err != nil {
+			return ScoreResult{}, err
+		}
+		if !matched {
+			continue
+		}
+		result.Total += rule.Weight
+		result.Contributions = append(result.Contributions, ScoreContribution{
+			RuleIdx:    i,
+			Weight:     rule.Weight,
+			Cumulative: result.Total,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package crux
+
+import "testing"
+
+// The scoring breakdown must account for every matched rule's weight and
+// sum exactly to the reported total, with cumulative values running in
+// rule order.
+func TestScoreAttribution(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "scoringset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				Weight:       30,
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 100.0}},
+				Weight:       50, // will not match; mrp is 50
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opLT, Val: 100.0}},
+				Weight:       12.5,
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	result, err := ScoreMatch("scoringset", inventoryEntity())
+	if err != nil {
+		t.Fatalf("ScoreMatch: %v", err)
+	}
+	if len(result.Contributions) != 2 {
+		t.Fatalf("got %d contributions, want 2: %+v", len(result.Contributions), result.Contributions)
+	}
+	var sum float64
+	for _, c := range result.Contributions {
+		sum += c.Weight
+		if c.Cumulative != sum {
+			t.Errorf("rule %d: cumulative %v, want %v", c.RuleIdx, c.Cumulative, sum)
+		}
+	}
+	if sum != result.Total {
+		t.Fatalf("contributions sum to %v but total is %v", sum, result.Total)
+	}
+	if result.Total != 42.5 {
+		t.Fatalf("total = %v, want 42.5", result.Total)
+	}
+}
@@ -0,0 +1,72 @@
+package crux
+
+import (
+	"fmt"
+	"log"
+)
+
+// UnknownAttrPolicy selects how the evaluator treats a rule whose pattern
+// references an attribute that is in neither the schema nor the action
+// schema — typically a rule compiled against an older schema version
+// whose attribute has since been removed.
+type UnknownAttrPolicy int
+
+const (
+	// UnknownAttrNoMatch treats the rule as not matching, so its
+	// ELSECALL, if any, still applies. This is the engine default.
+	UnknownAttrNoMatch UnknownAttrPolicy = iota
+	// UnknownAttrSkipRule skips the rule entirely, as if it were not in
+	// the ruleset.
+	UnknownAttrSkipRule
+	// UnknownAttrError aborts the evaluation with an error.
+	UnknownAttrError
+)
+
+// unknownAttrPolicy is the engine-wide policy for version-skewed rules.
+var unknownAttrPolicy = UnknownAttrNoMatch
+
+// SetUnknownAttrPolicy changes how the evaluator handles rules that
+// reference attributes missing from the schema, returning the previous
+// policy so callers can restore it.
+func SetUnknownAttrPolicy(p UnknownAttrPolicy) UnknownAttrPolicy {
+	prev := unknownAttrPolicy
+	unknownAttrPolicy = p
+	return prev
+}
+
+// ruleUnknownAttr returns the first attribute of the rule's pattern that
+// the schema knows nothing about: not a declared attribute, task or
+// property.
+func ruleUnknownAttr(rule *Rule, schema *RuleSchema) (string, bool) {
+	if schema == nil {
+		return "", false // schema-less passthrough: nothing to skew against
+	}
+	for i := range rule.RulePatterns {
+		attr := rule.RulePatterns[i].Attr
+		if getAttrSchema(schema, attr) != nil {
+			continue
+		}
+		if hasTask(&schema.ActionSchema, attr) || hasProperty(&schema.ActionSchema, attr) {
+			continue
+		}
+		return attr, true
+	}
+	return "", false
+}
+
+// applyUnknownAttrPolicy records a version-skewed rule and reports how
+// the caller should proceed: skipRule to pass over the rule silently, or
+// an error to abort the evaluation. Under UnknownAttrNoMatch neither is
+// set and the rule is treated as a plain non-match.
+func applyUnknownAttrPolicy(setName string, ruleIdx int, attr string) (skipRule bool, err error) {
+	engineMetrics.unknownAttrRules.Add(1)
+	log.Printf("crux: ruleset %q rule %d references attribute %q absent from the schema (policy %d)",
+		setName, ruleIdx, attr, unknownAttrPolicy)
+	switch unknownAttrPolicy {
+	case UnknownAttrSkipRule:
+		return true, nil
+	case UnknownAttrError:
+		return false, fmt.Errorf("ruleset %q rule %d references attribute %q absent from the schema", setName, ruleIdx, attr)
+	}
+	return false, nil
+}
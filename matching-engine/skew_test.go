@@ -0,0 +1,56 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+// A rule referencing an attribute removed from the schema after the rule
+// was compiled must follow the configured policy: treated as a plain
+// non-match, skipped outright, or an evaluation error.
+func TestUnknownAttrPolicy(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "skewedset",
+		Rules: []Rule{
+			{
+				// "vintage" was dropped from the schema; this rule is skewed.
+				RulePatterns: []RulePatternTerm{{Attr: "vintage", Op: opEQ, Val: true}},
+				RuleActions:  RuleActions{Properties: map[string]string{"shipby": "air"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	entity := inventoryEntity()
+
+	// Default: the skewed rule is a non-match, the healthy rule still fires.
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch under no-match policy: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("healthy rule did not fire under no-match policy: %v", actionSet.Tasks)
+	}
+
+	prev := SetUnknownAttrPolicy(UnknownAttrSkipRule)
+	defer SetUnknownAttrPolicy(prev)
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch under skip policy: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Fatalf("healthy rule did not fire under skip policy: %v", actionSet.Tasks)
+	}
+
+	SetUnknownAttrPolicy(UnknownAttrError)
+	_, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err == nil || !strings.Contains(err.Error(), "vintage") {
+		t.Fatalf("doMatch under error policy: got %v, want error naming the attribute", err)
+	}
+}
@@ -0,0 +1,85 @@
+package crux
+
+// Pattern terms AND together, and matchPattern already stops at the
+// first failing term. What order the terms run in is therefore free to
+// choose, so we run the cheap ones first: a failing bool or enum
+// equality then rules the pattern out before any timestamp is parsed or
+// regex compiled. AND is commutative, so the outcome is unchanged; only
+// the work to reach it shrinks.
+
+// termCostOrderingEnabled gates the reordering, so the effect can be
+// measured and the old author-order behaviour restored if needed.
+var termCostOrderingEnabled = true
+
+// SetTermCostOrdering switches cost-ordered term evaluation on or off,
+// returning the previous setting.
+func SetTermCostOrdering(enabled bool) bool {
+	prev := termCostOrderingEnabled
+	termCostOrderingEnabled = enabled
+	return prev
+}
+
+// Term cost tiers. The exact values only matter relative to each other.
+const (
+	costCheap     = 0 // bool/enum/string identity
+	costModerate  = 1 // numeric compares, membership, ranges
+	costExpensive = 2 // regex, timestamp/date parsing, aggregates, children
+)
+
+// termCost classifies how expensive a term is to evaluate.
+func termCost(term *RulePatternTerm, schema *RuleSchema) int {
+	as := getAttrSchema(schema, term.Attr)
+	if as != nil && (as.Aggregate != nil || as.Child != nil) {
+		return costExpensive
+	}
+	if term.Op == opMATCH {
+		return costExpensive
+	}
+	valType := typeStr
+	if as != nil {
+		valType = as.ValType
+	}
+	switch valType {
+	case typeTS, typeDate:
+		return costExpensive
+	case typeBool:
+		return costCheap
+	case typeEnum, typeStr:
+		if term.Op == opEQ || term.Op == opNE {
+			return costCheap
+		}
+	}
+	return costModerate
+}
+
+// patternOrder returns the indices of pattern in cost order, cheapest
+// first and author order preserved within a tier. It returns nil when
+// the pattern is already cost-ordered (the common case for small or
+// uniform patterns), so the caller can skip the indirection.
+func patternOrder(pattern []RulePatternTerm, schema *RuleSchema) []int {
+	if !termCostOrderingEnabled || len(pattern) < 2 {
+		return nil
+	}
+	ordered := true
+	prev := costCheap
+	costs := make([]int, len(pattern))
+	for i := range pattern {
+		costs[i] = termCost(&pattern[i], schema)
+		if costs[i] < prev {
+			ordered = false
+		}
+		prev = costs[i]
+	}
+	if ordered {
+		return nil
+	}
+	order := make([]int, 0, len(pattern))
+	for tier := costCheap; tier <= costExpensive; tier++ {
+		for i, cost := range costs {
+			if cost == tier {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
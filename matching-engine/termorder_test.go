@@ -0,0 +1,106 @@
+package crux
+
+import "testing"
+
+// Cost ordering must not change what matches: a pattern mixing cheap
+// and expensive terms gives the same result either way.
+func TestTermCostOrderingSameResult(t *testing.T) {
+	rs := wideRuleSet()
+	teardown := setupTestSchema(t, wideSchema(), rs)
+	defer teardown()
+
+	for _, cat := range []string{"textbook", "notebook"} {
+		entity := wideEntity()
+		entity.Attrs["cat"] = cat
+		var got [2]int
+		for i, enabled := range []bool{true, false} {
+			prev := SetTermCostOrdering(enabled)
+			actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+			SetTermCostOrdering(prev)
+			if err != nil {
+				t.Fatalf("doMatch cat=%s ordering=%v: %v", cat, enabled, err)
+			}
+			got[i] = len(actionSet.Tasks)
+		}
+		if got[0] != got[1] {
+			t.Errorf("cat=%s: ordered %d tasks, unordered %d", cat, got[0], got[1])
+		}
+	}
+}
+
+// wideSchema declares a class whose rules mix expensive terms
+// (timestamps, regex) with a cheap enum identity.
+func wideSchema() *RuleSchema {
+	return &RuleSchema{
+		Class: "wideitems",
+		PatternSchema: []AttrSchema{
+			{Name: "cat", ValType: typeEnum, Vals: map[string]struct{}{"textbook": {}, "notebook": {}}},
+			{Name: "sku", ValType: typeStr},
+			{Name: "received", ValType: typeTS},
+			{Name: "inspected", ValType: typeTS},
+			{Name: "shipped", ValType: typeTS},
+		},
+		ActionSchema: ActionSchema{Tasks: []string{"restock"}},
+	}
+}
+
+// wideRuleSet puts the expensive terms first in author order and the
+// cheap discriminating enum last, the worst case for naive evaluation.
+func wideRuleSet() *RuleSet {
+	return &RuleSet{
+		Id:      1,
+		Class:   "wideitems",
+		SetName: "wideset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{
+				{Attr: "received", Op: opGT, Val: "2024-01-01T00:00:00Z"},
+				{Attr: "inspected", Op: opGT, Val: "2024-01-02T00:00:00Z"},
+				{Attr: "shipped", Op: opGT, Val: "2024-01-03T00:00:00Z"},
+				{Attr: "sku", Op: opMATCH, Val: "^BK-[0-9]+$"},
+				{Attr: "cat", Op: opEQ, Val: "textbook"},
+			},
+			RuleActions: RuleActions{Tasks: []string{"restock"}},
+		}},
+	}
+}
+
+func wideEntity() Entity {
+	return Entity{
+		Class: "wideitems",
+		Attrs: map[string]string{
+			"cat":       "notebook",
+			"sku":       "BK-1234",
+			"received":  "2024-02-01T00:00:00Z",
+			"inspected": "2024-02-02T00:00:00Z",
+			"shipped":   "2024-02-03T00:00:00Z",
+		},
+	}
+}
+
+// The benchmark pair shows what ordering buys on a wide pattern whose
+// cheap final term rules the match out.
+func BenchmarkWidePatternOrdered(b *testing.B)   { benchWidePattern(b, true) }
+func BenchmarkWidePatternUnordered(b *testing.B) { benchWidePattern(b, false) }
+
+func benchWidePattern(b *testing.B, ordered bool) {
+	prevSchemas, prevSets := ruleSchemas, ruleSets
+	ruleSchemas = []*RuleSchema{wideSchema()}
+	rs := wideRuleSet()
+	ruleSets = map[string]*RuleSet{rs.SetName: rs}
+	invalidateSchemaIndex()
+	prev := SetTermCostOrdering(ordered)
+	defer func() {
+		SetTermCostOrdering(prev)
+		ruleSchemas, ruleSets = prevSchemas, prevSets
+		invalidateSchemaIndex()
+	}()
+
+	entity := wideEntity() // cat=notebook fails the cheap last term
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package crux
+
+import "fmt"
+
+// TieBreakPolicy selects which rule wins under first-match semantics
+// when several rules of equal priority match the same entity.
+type TieBreakPolicy int
+
+const (
+	// TieBreakSourceOrder picks the rule appearing first in the ruleset.
+	// This is the default, matching the order-sensitive semantics of
+	// doMatch.
+	TieBreakSourceOrder TieBreakPolicy = iota
+	// TieBreakRuleID picks the rule with the lowest Id.
+	TieBreakRuleID
+	// TieBreakMostSpecific picks the rule whose pattern has the most
+	// terms, on the reasoning that a narrower pattern encodes a more
+	// deliberate decision; ties within that fall back to source order.
+	TieBreakMostSpecific
+)
+
+// FirstMatch evaluates a loaded ruleset under first-match-wins
+// semantics with the caller's tie-break policy, verifying the entity
+// first. It returns the winning rule's index, or -1 when no rule
+// matches.
+func FirstMatch(setName string, entity Entity, policy TieBreakPolicy) (int, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return -1, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return -1, err
+	}
+	return firstMatch(entity, rs, policy)
+}
+
+// firstMatch evaluates rs under first-match-wins semantics: among the
+// matching rules of the highest priority, the tie-break policy selects
+// the single winner. It returns the winner's index, or -1 when no rule
+// matches. Confidence and trust floors gate a match exactly as they do
+// in doMatch.
+func firstMatch(entity Entity, rs *RuleSet, policy TieBreakPolicy) (int, error) {
+	schema := schemaFor(rs)
+	if schema == nil {
+		return -1, fmt.Errorf("no schema found for class %q", rs.Class)
+	}
+	actionSet := ActionSet{Properties: map[string]string{}}
+	winner := -1
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		matched, quality, err := matchPattern(entity, rule.RulePatterns, actionSet, schema)
+		if err != nil {
+			return -1, err
+		}
+		if !matched {
+			continue
+		}
+		if rule.MinConfidence > 0 && quality.confidence < rule.MinConfidence {
+			continue // pattern holds but the inputs are not confident enough
+		}
+		if rule.MinTrust > 0 && quality.trust < rule.MinTrust {
+			continue // pattern holds but an attribute's source is not trusted enough
+		}
+		if winner < 0 || beats(rule, i, &rs.Rules[winner], winner, policy) {
+			winner = i
+		}
+	}
+	return winner, nil
+}
+
+// beats reports whether the challenger rule outranks the incumbent under
+// the policy. Higher priority always wins; the policy only breaks ties.
+func beats(challenger *Rule, chIdx int, incumbent *Rule, incIdx int, policy TieBreakPolicy) bool {
+	if challenger.Priority != incumbent.Priority {
+		return challenger.Priority > incumbent.Priority
+	}
+	switch policy {
+	case TieBreakRuleID:
+		return challenger.Id < incumbent.Id
+	case TieBreakMostSpecific:
+		return len(challenger.RulePatterns) > len(incumbent.RulePatterns)
+	}
+	// TieBreakSourceOrder: the earlier rule stands.
+	return chIdx < incIdx
+}
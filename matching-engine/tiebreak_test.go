@@ -0,0 +1,72 @@
+package crux
+
+import "testing"
+
+// Two equal-priority rules both match; the tie-break policy decides the
+// winner under first-match semantics.
+func TestTieBreakPolicies(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "tiebreakset",
+		Rules: []Rule{
+			{
+				Id:           20,
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Properties: map[string]string{"shipby": "road"}},
+			},
+			{
+				Id: 10,
+				RulePatterns: []RulePatternTerm{
+					{Attr: "cat", Op: opEQ, Val: "textbook"},
+					{Attr: "mrp", Op: opLT, Val: 100.0},
+				},
+				RuleActions: RuleActions{Properties: map[string]string{"shipby": "air"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+	entity := inventoryEntity()
+
+	cases := []struct {
+		policy TieBreakPolicy
+		want   int
+	}{
+		{TieBreakSourceOrder, 0},
+		{TieBreakRuleID, 1},
+		{TieBreakMostSpecific, 1},
+	}
+	for _, tc := range cases {
+		got, err := FirstMatch("tiebreakset", entity, tc.policy)
+		if err != nil {
+			t.Fatalf("FirstMatch(policy %d): %v", tc.policy, err)
+		}
+		if got != tc.want {
+			t.Errorf("FirstMatch(policy %d) = rule %d, want rule %d", tc.policy, got, tc.want)
+		}
+	}
+
+	// A confidence floor disqualifies a rule here exactly as in doMatch.
+	rs.Rules[1].MinConfidence = 0.8
+	entity.Confidence = map[string]float64{"mrp": 0.5}
+	got, err := FirstMatch("tiebreakset", entity, TieBreakMostSpecific)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("low-confidence rule won the tie-break: got rule %d", got)
+	}
+	rs.Rules[1].MinConfidence = 0
+	entity.Confidence = nil
+
+	// A higher-priority rule wins regardless of the tie-break.
+	rs.Rules[0].Priority = 5
+	got, err = FirstMatch("tiebreakset", entity, TieBreakMostSpecific)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("higher-priority rule lost the tie-break: got rule %d", got)
+	}
+}
@@ -0,0 +1,65 @@
+package crux
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Trace captures what one sampled evaluation did, for later inspection
+// by correlation ID.
+type Trace struct {
+	CorrelationID   string   `json:"correlation_id"`
+	RuleSetsEntered []string `json:"rulesets_entered"`
+	Invocations     int      `json:"invocations"`
+}
+
+// traceSamplePercent is the engine-wide trace sampling rate in percent.
+// Sampling also needs a correlation ID on the request; without one there
+// is no key to retrieve the trace by.
+var traceSamplePercent int
+
+// SetTraceSampling sets the trace sampling rate in percent and returns
+// the previous rate. 0 disables sampling; 100 traces every evaluation
+// that carries a correlation ID.
+func SetTraceSampling(percent int) int {
+	prev := traceSamplePercent
+	traceSamplePercent = percent
+	return prev
+}
+
+// traceStore holds sampled traces keyed by correlation ID.
+var traceStore = struct {
+	mu     sync.Mutex
+	traces map[string]*Trace
+}{traces: map[string]*Trace{}}
+
+// storeTrace saves a completed trace for retrieval.
+func storeTrace(tr *Trace) {
+	traceStore.mu.Lock()
+	defer traceStore.mu.Unlock()
+	traceStore.traces[tr.CorrelationID] = tr
+}
+
+// GetTrace returns the sampled trace for a correlation ID, if one was
+// captured.
+func GetTrace(correlationID string) (*Trace, bool) {
+	traceStore.mu.Lock()
+	defer traceStore.mu.Unlock()
+	tr, ok := traceStore.traces[correlationID]
+	return tr, ok
+}
+
+// shouldSampleTrace decides whether an evaluation captures a trace. The
+// decision hashes the correlation ID, so it is deterministic per ID: the
+// retries of a request are either all traced or all untraced.
+func shouldSampleTrace(correlationID string, percent int) bool {
+	if percent <= 0 || correlationID == "" {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(correlationID))
+	return int(h.Sum32()%100) < percent
+}
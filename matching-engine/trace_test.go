@@ -0,0 +1,61 @@
+package crux
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceSamplingDeterministic(t *testing.T) {
+	for _, id := range []string{"corr-a", "corr-b", "corr-c", "corr-d"} {
+		first := shouldSampleTrace(id, 50)
+		for i := 0; i < 10; i++ {
+			if shouldSampleTrace(id, 50) != first {
+				t.Fatalf("sampling decision for %q not deterministic", id)
+			}
+		}
+	}
+	if shouldSampleTrace("anything", 0) {
+		t.Errorf("0%% sampled a trace")
+	}
+	if !shouldSampleTrace("anything", 100) {
+		t.Errorf("100%% did not sample")
+	}
+	if shouldSampleTrace("", 100) {
+		t.Errorf("sampled without a correlation ID")
+	}
+}
+
+func TestSampledTraceStored(t *testing.T) {
+	rs := &RuleSet{
+		Class:   "inventoryitems",
+		SetName: "main",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	defer setupTestSchema(t, inventorySchema(), rs)()
+
+	cfg := matchConfig{correlationID: "corr-traced", tracePercent: 100}
+	if _, _, err := doMatchWithConfig(inventoryEntity(), rs, ActionSet{}, map[string]struct{}{}, cfg); err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	tr, ok := GetTrace("corr-traced")
+	if !ok {
+		t.Fatalf("no trace stored for sampled evaluation")
+	}
+	if len(tr.RuleSetsEntered) != 1 || tr.RuleSetsEntered[0] != "main" || tr.Invocations != 1 {
+		t.Errorf("trace = %+v, want main entered once", tr)
+	}
+
+	// The exported configuration reaches evaluations through Match.
+	prev := SetTraceSampling(100)
+	defer SetTraceSampling(prev)
+	opts := MatchOptions{CorrelationID: "corr-exported"}
+	if _, err := MatchWithOptions(context.Background(), "main", inventoryEntity(), opts); err != nil {
+		t.Fatalf("MatchWithOptions: %v", err)
+	}
+	if _, ok := GetTrace("corr-exported"); !ok {
+		t.Errorf("no trace stored for an evaluation sampled via SetTraceSampling")
+	}
+}
@@ -0,0 +1,55 @@
+package crux
+
+import (
+	"fmt"
+	"time"
+)
+
+// Comparison granularities a typeTS attribute may declare. Both sides of
+// a comparison are truncated to the declared resolution, so values
+// differing only below it compare equal.
+const (
+	granSecond = "second"
+	granMinute = "minute"
+	granHour   = "hour"
+	granDay    = "day"
+)
+
+// granularityDuration maps a declared granularity to the truncation
+// interval it stands for.
+func granularityDuration(name string) (time.Duration, error) {
+	switch name {
+	case granSecond:
+		return time.Second, nil
+	case granMinute:
+		return time.Minute, nil
+	case granHour:
+		return time.Hour, nil
+	case granDay:
+		return 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown timestamp granularity %q", name)
+}
+
+// evalGranularTSTerm compares a timestamp term at the attribute's
+// declared granularity, truncating both the entity value and the term
+// value before applying the operator.
+func evalGranularTSTerm(entityVal any, term *RulePatternTerm, as *AttrSchema) (bool, error) {
+	ev, ok := entityVal.(time.Time)
+	if !ok {
+		return false, fmt.Errorf("type mismatch comparing timestamp values")
+	}
+	tv, ok := term.Val.(string)
+	if !ok {
+		return false, fmt.Errorf("type mismatch comparing timestamp values")
+	}
+	tvt, err := time.Parse(timeLayout, tv)
+	if err != nil {
+		return false, err
+	}
+	interval, err := granularityDuration(as.TSGranularity)
+	if err != nil {
+		return false, err
+	}
+	return compareTimes(ev.Truncate(interval), tvt.Truncate(interval), term.Op)
+}
@@ -0,0 +1,55 @@
+package crux
+
+import "testing"
+
+// Two timestamps differing by milliseconds compare equal once the
+// attribute declares second granularity; without it they differ. An
+// unknown granularity, or one on a non-timestamp attribute, is rejected
+// at save.
+func TestTSComparisonGranularity(t *testing.T) {
+	schema := inventorySchema()
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "granset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "received", Op: opEQ, Val: "2024-05-01T10:00:00Z"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	entity := inventoryEntity()
+	entity.Attrs["received"] = "2024-05-01T10:00:00.250Z"
+
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("sub-second difference compared equal without a granularity: %+v", actionSet)
+	}
+
+	if as := getAttrSchema(schema, "received"); as != nil {
+		as.TSGranularity = granSecond
+	}
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("timestamps did not compare equal at second granularity: %+v", actionSet)
+	}
+
+	badSchema := inventorySchema()
+	getAttrSchema(badSchema, "received").TSGranularity = "fortnight"
+	if err := verifyRuleSchema(badSchema, false); err == nil {
+		t.Fatalf("unknown granularity accepted at save")
+	}
+	badSchema = inventorySchema()
+	getAttrSchema(badSchema, "mrp").TSGranularity = granSecond
+	if err := verifyRuleSchema(badSchema, false); err == nil {
+		t.Fatalf("granularity on a non-timestamp attribute accepted at save")
+	}
+}
@@ -0,0 +1,77 @@
+package crux
+
+// verifyAndConvertEntity verifies an entity and returns a copy whose
+// attribute values have additionally been converted to their native Go
+// types (int, float64, bool, time.Time), so matching can compare them
+// without re-parsing the same strings term after term. The input entity
+// is left untouched; callers that keep it see only strings.
+func verifyAndConvertEntity(e Entity) (Entity, error) {
+	if err := verifyEntity(e); err != nil {
+		return Entity{}, err
+	}
+	schema := scopedRuleSchema(e.App, e.Class)
+	if schema == nil {
+		// Verification passed without one only under the passthrough
+		// policy; there are no types or defaults to apply.
+		return e, nil
+	}
+	converted := e
+	converted.Attrs = withDefaults(e.Attrs, schema)
+	converted.typed = make(map[string]any, len(converted.Attrs))
+	for name, val := range converted.Attrs {
+		as := getAttrSchema(schema, name)
+		if as == nil || as.Aggregate != nil || as.Child != nil {
+			continue
+		}
+		typed, err := convertEntityAttrVal(canonicalizeVal(val, as), as.ValType, as)
+		if err != nil {
+			// Verification vouched for the value; a conversion failure
+			// here means the two disagree and must not pass silently.
+			return Entity{}, attrError(name, "entity of class %q: %v", e.Class, err)
+		}
+		converted.typed[name] = typed
+	}
+	return converted, nil
+}
+
+// withDefaults fills schema-declared defaults in for absent attributes
+// — or, under PrecedenceDefault, pins every defaulted attribute to its
+// declared value — copying the map first so the caller's entity stays
+// untouched. Without any default to apply the original map is returned
+// as-is.
+func withDefaults(attrs map[string]string, schema *RuleSchema) map[string]string {
+	var filled map[string]string
+	for i := range schema.PatternSchema {
+		as := &schema.PatternSchema[i]
+		if as.Default == "" {
+			continue
+		}
+		if _, ok := attrs[as.Name]; ok && attrPrecedence != PrecedenceDefault {
+			continue
+		}
+		if filled == nil {
+			filled = make(map[string]string, len(attrs)+1)
+			for k, v := range attrs {
+				filled[k] = v
+			}
+		}
+		filled[as.Name] = as.Default
+	}
+	if filled == nil {
+		return attrs
+	}
+	return filled
+}
+
+// typedEntityAttrVal resolves the typed form of a term's input: the
+// value pre-converted by verifyAndConvertEntity when the input came from
+// the entity's own attributes, else a fresh conversion (properties and
+// tasks accumulated during evaluation have no cache).
+func typedEntityAttrVal(entity Entity, attr, entityVal, valType string, as *AttrSchema) (any, error) {
+	if typed, ok := entity.typed[attr]; ok {
+		if _, fromEntity := entity.Attrs[attr]; fromEntity {
+			return typed, nil
+		}
+	}
+	return convertEntityAttrVal(entityVal, valType, as)
+}
@@ -0,0 +1,94 @@
+package crux
+
+import (
+	"testing"
+	"time"
+)
+
+// verifyAndConvertEntity returns a typed copy and leaves the original
+// untouched; matching consumes the cached typed values.
+func TestVerifyAndConvertEntity(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "typedset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 100.0}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	entity := inventoryEntity()
+	entity.Attrs["received"] = "2024-03-15T10:00:00Z"
+	converted, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		t.Fatalf("verifyAndConvertEntity: %v", err)
+	}
+	if entity.typed != nil {
+		t.Fatalf("original entity was given a typed cache")
+	}
+	if mrp, ok := converted.typed["mrp"].(float64); !ok || mrp != 50 {
+		t.Errorf("typed mrp = %v, want float64 50", converted.typed["mrp"])
+	}
+	if _, ok := converted.typed["received"].(time.Time); !ok {
+		t.Errorf("typed received = %T, want time.Time", converted.typed["received"])
+	}
+
+	// Matching reads the cache: a poisoned typed value decides the
+	// match even though the string attribute says otherwise.
+	converted.typed["mrp"] = 999.0
+	actionSet, _, err := doMatch(converted, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("cached typed value not consulted: %+v", actionSet)
+	}
+
+	entity.Attrs["mrp"] = "notanumber"
+	if _, err := verifyAndConvertEntity(entity); err == nil {
+		t.Errorf("invalid entity passed verifyAndConvertEntity")
+	}
+}
+
+// A schema-declared default stands in for a missing attribute: the
+// entity verifies, the converted copy carries the default (typed), and
+// the original map is untouched. A default that fails the attribute's
+// own type check is rejected at schema save.
+func TestDefaultValueInjected(t *testing.T) {
+	schema := inventorySchema()
+	for i := range schema.PatternSchema {
+		if schema.PatternSchema[i].Name == "mrp" {
+			schema.PatternSchema[i].Default = "25"
+		}
+	}
+	teardown := setupTestSchema(t, schema, nil)
+	defer teardown()
+
+	entity := inventoryEntity()
+	delete(entity.Attrs, "mrp")
+	converted, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		t.Fatalf("verifyAndConvertEntity: %v", err)
+	}
+	if converted.Attrs["mrp"] != "25" {
+		t.Errorf("default not injected: %v", converted.Attrs)
+	}
+	if mrp, ok := converted.typed["mrp"].(float64); !ok || mrp != 25 {
+		t.Errorf("typed default = %v, want float64 25", converted.typed["mrp"])
+	}
+	if _, ok := entity.Attrs["mrp"]; ok {
+		t.Errorf("default leaked into the caller's entity: %v", entity.Attrs)
+	}
+
+	for i := range schema.PatternSchema {
+		if schema.PatternSchema[i].Name == "mrp" {
+			schema.PatternSchema[i].Default = "cheap"
+		}
+	}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("mistyped default accepted at schema save")
+	}
+}
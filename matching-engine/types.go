@@ -0,0 +1,304 @@
+// Package crux implements the Remiges Crux matching engine: rule schemas,
+// rulesets, entities and the matching algorithm that evaluates an entity
+// against a ruleset to produce a set of actions.
+package crux
+
+import "time"
+
+// Value types an attribute in a pattern schema may take.
+const (
+	typeBool  = "bool"
+	typeInt   = "int"
+	typeFloat = "float"
+	typeStr   = "str"
+	typeEnum  = "enum"
+	typeTS    = "ts"
+)
+
+// Comparison operators allowed in rule pattern terms.
+const (
+	opEQ = "eq"
+	opNE = "ne"
+	opLT = "lt"
+	opLE = "le"
+	opGT = "gt"
+	opGE = "ge"
+)
+
+// Names reserved by the workflow engine.
+const (
+	stepAttr       = "step"
+	stepFailedAttr = "stepfailed"
+	startStep      = "START"
+	nextStepProp   = "nextstep"
+	doneProp       = "done"
+)
+
+// Entity is the thing being matched: an instance of a class carrying
+// attribute values, all transported as strings and typed by the schema.
+// Confidence optionally carries a 0-1 confidence per attribute for
+// fuzzy or ML-derived inputs; attributes absent from the map count as
+// fully trusted.
+type Entity struct {
+	Realm      string
+	App        string
+	Class      string
+	Attrs      map[string]string
+	Confidence map[string]float64
+	Provenance map[string]AttrSource
+	Events     map[string][]AttrEvent // event windows feeding aggregate attributes
+	Children   map[string][]Entity    // named child collections feeding child-aggregate attributes
+
+	// typed caches attribute values already converted to their native Go
+	// types, populated by verifyAndConvertEntity so matching does not
+	// re-parse the same strings term after term.
+	typed map[string]any
+}
+
+// AttrSource records where an attribute value came from and how much the
+// source is trusted (0-1), for data-fusion scenarios where rules demand
+// a minimum trust. Attributes without provenance count as fully trusted.
+type AttrSource struct {
+	Source     string
+	Trust      float64
+	CapturedAt time.Time // when the value was captured; zero means unknown
+}
+
+// RulePatternTerm is one attr-op-value term of a rule pattern. All terms
+// of a pattern must hold for the rule to match. Negate inverts the
+// term's result after evaluation, so any operator can be negated
+// without a dedicated inverse operator; an absent attribute still fails
+// the pattern either way.
+type RulePatternTerm struct {
+	Attr   string
+	Op     string
+	Val    any
+	Negate bool
+	// CaseInsensitive lower-cases both operands before comparison; only
+	// string and enum attributes may carry it. See foldedTerm.
+	CaseInsensitive bool
+}
+
+// RuleActions is what a rule does when its pattern matches. Enrich, if
+// set, names a registered EnrichmentFunc run after the rule matches to
+// populate further output properties from an external source. UseMacro
+// names a registered action macro whose block is merged in at load; see
+// expandActionMacros.
+type RuleActions struct {
+	Tasks      []string
+	Properties map[string]string
+	UseMacro   string
+	Enrich     string
+	ThenCall   string
+	ElseCall   string
+	WillReturn bool
+	WillExit   bool
+}
+
+// Rule pairs a pattern with the actions to take when it matches.
+// MinConfidence and MinTrust, when non-zero, keep the rule from firing
+// unless the aggregate confidence respectively source trust of the
+// entity attributes its pattern references reaches the threshold.
+type Rule struct {
+	Id            int
+	RulePatterns  []RulePatternTerm
+	RuleActions   RuleActions
+	Priority      int
+	Weight        float64 // contribution to the total in scoring mode; see scoreMatch
+	MinConfidence float64
+	MinTrust      float64
+}
+
+// RuleSet is a named, ordered collection of rules for one class.
+// PinnedSchemaVer, when non-zero, pins the ruleset to the schema version
+// it was verified against, so advancing the active schema cannot
+// silently change its semantics.
+type RuleSet struct {
+	Id              int
+	Realm           string
+	App             string // owning app; empty means shared across the realm
+	Class           string
+	SetName         string
+	Rules           []Rule
+	PinnedSchemaVer int
+}
+
+// ActionSet accumulates the tasks and properties emitted by matched rules
+// over the course of one evaluation.
+type ActionSet struct {
+	Tasks      []string
+	Properties map[string]string
+}
+
+// AttrCondition names another attribute and a value for it, used to make
+// an attribute's presence conditional.
+type AttrCondition struct {
+	Attr string
+	Val  string
+}
+
+// AttrSchema describes one attribute in a pattern schema: its type and
+// the constraints on its values. RequiredIf, when set, makes the
+// attribute required only while the named attribute carries the given
+// value (e.g. rejectionreason only when status=rejected); otherwise the
+// attribute may be absent.
+type AttrSchema struct {
+	Name          string
+	Description   string // human documentation for tooling; never affects evaluation
+	ValType       string
+	ElemType      string              // scalar type of each element, for typeList
+	Vals          map[string]struct{} // permitted values, for typeEnum
+	OrderedVals   []string            // the enum's values in rank order, enabling LT/LE/GT/GE
+	ValMin        float64
+	ValMax        float64
+	MaxMagnitude  float64 // bound on |value| for numeric types; 0 uses the engine-wide default
+	LenMin        int
+	LenMax        int
+	Optional      bool // the entity may omit the attribute outright; see verifyEntityWithMode
+	RequiredIf    *AttrCondition
+	Canonical     string         // name of a registered canonicalization table
+	Aggregate     *AggregateSpec // value computed from an event window; see verifyAggregateSpec
+	Default       string         // value used when the entity omits the attribute; see resolveEntityAttrs
+	Derive        *DeriveSpec    // value computed from other attributes; see deriveAttrVal
+	MaxAge        time.Duration  // freshness TTL measured from the provenance capture time; 0 means no requirement
+	TSGranularity string         // comparison resolution for typeTS values; see granularityDuration
+	Child         *ChildSpec     // value aggregated over a child collection; see verifyChildSpec
+}
+
+// ActionSchema declares the tasks and properties that rules of a class
+// are allowed to emit. PropFormats optionally maps a property name to an
+// output format applied to its value when a rule sets it; see
+// formatPropertyVal for the format syntax. RequiredTasks names tasks
+// that every successful evaluation is expected to produce; callers can
+// opt in to having their absence treated as an error.
+type ActionSchema struct {
+	Tasks           []string
+	TaskDescs       map[string]string // human documentation per task
+	Properties      []string
+	PropFormats     map[string]string
+	PropConstraints map[string]PropConstraint
+	RequiredTasks   []string
+}
+
+// PropConstraint tightens the contract on one declared property: Vals,
+// when non-empty, enumerates the values rules may set it to; Single
+// restricts the property to at most one rule per ruleset, for outputs
+// where a second setter is almost certainly an authoring mistake.
+type PropConstraint struct {
+	Vals   []string
+	Single bool
+}
+
+// RuleSchema is the full schema for one class: the attributes entities of
+// the class carry, and the actions rules of the class may take. Version
+// distinguishes revisions of a class's schema; 0 is treated as version 1.
+type RuleSchema struct {
+	Class         string
+	App           string // owning app; empty means shared across the realm
+	Description   string // human documentation for the class
+	Version       int
+	Revision      int       // edit counter for optimistic concurrency; see UpdateRuleSchema
+	Inactive      bool      // deactivated drafts stay loaded but are never picked for evaluation
+	DeletedAt     time.Time // soft-delete tombstone; zero means live. See DeleteSchema
+	PatternSchema []AttrSchema
+	ActionSchema  ActionSchema
+	Pipeline      []string // ordered pre-evaluation transforms; see prepareEntity
+}
+
+// ruleSchemas holds the schemas currently loaded. A class may appear
+// with several versions; the highest version is the active one.
+var ruleSchemas []*RuleSchema
+
+// ruleSets holds the rulesets currently loaded, keyed by set name.
+var ruleSets = map[string]*RuleSet{}
+
+// getRuleSchema returns the active (highest-version) schema for class,
+// or nil if none is loaded. Lookups go through the class index rather
+// than scanning ruleSchemas; see schemaindex.go.
+func getRuleSchema(class string) *RuleSchema {
+	return indexedSchema("", class)
+}
+
+// scopedRuleSchema returns the active schema for a class as seen from
+// one app: the app's own schema when it has one, else the realm-shared
+// schema. Two apps may therefore use the same class name without
+// colliding.
+func scopedRuleSchema(app, class string) *RuleSchema {
+	if app != "" {
+		if schema := indexedSchema(app, class); schema != nil {
+			return schema
+		}
+	}
+	return indexedSchema("", class)
+}
+
+// SaveRuleSchema verifies a schema and stores it among the loaded
+// schemas, documentation fields included, at revision 1.
+func SaveRuleSchema(schema *RuleSchema, isWF bool) error {
+	if err := verifyRuleSchema(schema, isWF); err != nil {
+		return err
+	}
+	schema.Revision = 1
+	ruleSchemas = append(ruleSchemas, schema)
+	invalidateSchemaIndex()
+	publishChange(ChangeEvent{Kind: changeKindSchema, Name: schema.Class, Version: schema.Version})
+	return nil
+}
+
+// GetSchema returns the active schema for a class, for callers outside
+// the engine such as the schema endpoints.
+func GetSchema(class string) (*RuleSchema, bool) {
+	schema := getRuleSchema(class)
+	return schema, schema != nil
+}
+
+// getRuleSchemaVer returns a specific version of a class's schema.
+func getRuleSchemaVer(class string, version int) *RuleSchema {
+	for _, rs := range ruleSchemas {
+		if rs.Class == class && rs.Version == version {
+			return rs
+		}
+	}
+	return nil
+}
+
+// schemaFor resolves the schema a ruleset evaluates against: its pinned
+// version when it has one, else the class's active schema.
+func schemaFor(rs *RuleSet) *RuleSchema {
+	if rs.PinnedSchemaVer > 0 {
+		return getRuleSchemaVer(rs.Class, rs.PinnedSchemaVer)
+	}
+	return scopedRuleSchema(rs.App, rs.Class)
+}
+
+// pinnedRuleSets lists loaded rulesets of a class pinned to a version
+// older than activeVer; schema activation surfaces these as warnings.
+func pinnedRuleSets(class string, activeVer int) []string {
+	var pinned []string
+	for name, rs := range ruleSets {
+		if rs.Class == class && rs.PinnedSchemaVer > 0 && rs.PinnedSchemaVer < activeVer {
+			pinned = append(pinned, name)
+		}
+	}
+	return pinned
+}
+
+// enumOrdinal returns a value's rank within an ordered enum.
+func enumOrdinal(as *AttrSchema, val string) (int, bool) {
+	for i, v := range as.OrderedVals {
+		if v == val {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// getAttrSchema returns the schema of the named attribute within rs, or
+// nil if the attribute is not declared. Lookups go through the
+// per-schema attribute index; see schemaindex.go.
+func getAttrSchema(rs *RuleSchema, name string) *AttrSchema {
+	if rs == nil {
+		return nil
+	}
+	return indexedAttr(rs, name)
+}
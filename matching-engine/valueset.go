@@ -0,0 +1,150 @@
+package crux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Membership operators. Their term value is either an inline list of
+// values of the attribute's type, or a set reference of the form
+// "@name" naming a value-set registered out of band, so a blocklist of
+// thousands of entries does not have to be inlined into every ruleset.
+const (
+	opIN    = "in"
+	opNOTIN = "notin"
+)
+
+// setRefPrefix marks a term value as a reference to a named value-set.
+const setRefPrefix = "@"
+
+// valueSets holds the named sets membership terms may reference, built
+// as maps so a lookup stays O(1) however large the set grows.
+var valueSets = map[string]map[string]struct{}{}
+
+// RegisterValueSet stores a named value-set for IN/NOT-IN terms to
+// reference, replacing any previous set of the same name. Updating a set
+// takes effect on the next evaluation; rulesets referencing it need no
+// change.
+func RegisterValueSet(name string, values []string) error {
+	if !attrNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid value-set name %q", name)
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	valueSets[name] = set
+	return nil
+}
+
+// verifySetTerm checks a membership term at save. A "@name" reference
+// must name an already-registered value-set; an inline list must be
+// non-empty with every element a legal value for the attribute.
+func verifySetTerm(rs *RuleSet, ruleIdx int, term *RulePatternTerm, as *AttrSchema) error {
+	if name, ok := setRefName(term.Val); ok {
+		set, registered := valueSets[name]
+		if !registered {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q references unknown value-set %q", rs.SetName, ruleIdx, term.Attr, name)
+		}
+		// Against an enum attribute, every member of the referenced set
+		// must be a declared value — an undeclared member is a typo
+		// that would otherwise silently never match.
+		if as != nil && as.ValType == typeEnum {
+			for v := range set {
+				if _, declared := as.Vals[v]; !declared {
+					return fmt.Errorf("ruleset %q rule %d: value-set %q holds %q, not a permitted value for attribute %q", rs.SetName, ruleIdx, name, v, term.Attr)
+				}
+			}
+		}
+		return nil
+	}
+	elems, ok := termValList(term.Val)
+	if !ok {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: membership term needs a value list or a %q-prefixed value-set reference", rs.SetName, ruleIdx, term.Attr, setRefPrefix)
+	}
+	if len(elems) == 0 {
+		return fmt.Errorf("ruleset %q rule %d: attribute %q: membership term has an empty value list", rs.SetName, ruleIdx, term.Attr)
+	}
+	for _, elem := range elems {
+		// Each element must be a legal equality value for the attribute.
+		elemTerm := RulePatternTerm{Attr: term.Attr, Op: opEQ, Val: elem}
+		if err := verifyTermVal(rs, ruleIdx, &elemTerm, as); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalSetTerm tests the entity value's membership in the term's set,
+// whether a named value-set or an inline list. Inline list elements are
+// compared at the attribute's type; named sets hold plain strings.
+func evalSetTerm(entityVal string, term *RulePatternTerm, as *AttrSchema, coll CollatorFunc) (bool, error) {
+	member, err := setTermHolds(entityVal, term, as, coll)
+	if err != nil {
+		return false, err
+	}
+	if term.Op == opNOTIN {
+		return !member, nil
+	}
+	return member, nil
+}
+
+// setTermHolds reports plain membership, before NOT-IN inversion.
+func setTermHolds(entityVal string, term *RulePatternTerm, as *AttrSchema, coll CollatorFunc) (bool, error) {
+	if name, ok := setRefName(term.Val); ok {
+		set, registered := valueSets[name]
+		if !registered {
+			return false, fmt.Errorf("value-set %q not registered", name)
+		}
+		_, member := set[entityVal]
+		return member, nil
+	}
+	elems, ok := termValList(term.Val)
+	if !ok {
+		return false, fmt.Errorf("membership term needs a value list or a %q-prefixed value-set reference", setRefPrefix)
+	}
+	valType := typeStr
+	if as != nil {
+		valType = as.ValType
+	}
+	typedVal, err := convertEntityAttrVal(entityVal, valType, as)
+	if err != nil {
+		return false, err
+	}
+	for _, elem := range elems {
+		holds, err := evalTerm(typedVal, opEQ, elem, valType, coll)
+		if err != nil {
+			return false, err
+		}
+		if holds {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// setRefName extracts the set name from a "@name" term value, reporting
+// whether the value is such a reference at all.
+func setRefName(val any) (string, bool) {
+	ref, ok := val.(string)
+	if !ok || !strings.HasPrefix(ref, setRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, setRefPrefix), true
+}
+
+// termValList normalizes an inline membership list. JSON-loaded rules
+// carry []any; Go-authored rulesets often use []string.
+func termValList(val any) ([]any, bool) {
+	switch v := val.(type) {
+	case []any:
+		return v, true
+	case []string:
+		elems := make([]any, len(v))
+		for i, s := range v {
+			elems[i] = s
+		}
+		return elems, true
+	}
+	return nil, false
+}
@@ -0,0 +1,148 @@
+package crux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// A membership term resolves against a stored 1000-element set without
+// inlining it; NOT-IN inverts it, and a reference to an unregistered set
+// is rejected at save.
+func TestValueSetMembership(t *testing.T) {
+	values := make([]string, 1000)
+	for i := range values {
+		values[i] = fmt.Sprintf("sku%04d", i)
+	}
+	if err := RegisterValueSet("blockedskus", values); err != nil {
+		t.Fatalf("RegisterValueSet: %v", err)
+	}
+	defer delete(valueSets, "blockedskus")
+
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "sku", ValType: typeStr})
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "blockset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "sku", Op: opIN, Val: "@blockedskus"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	entity := inventoryEntity()
+	entity.Attrs["sku"] = "sku0742"
+	actionSet, _, err := doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("member of the set did not match: %+v", actionSet)
+	}
+
+	entity.Attrs["sku"] = "sku9999"
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("non-member matched an IN term: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0].Op = opNOTIN
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("non-member did not match a NOT-IN term: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0].Val = "@nosuchset"
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("reference to unregistered value-set accepted at save")
+	}
+}
+
+// An inline IN list matches any listed value at the attribute's type;
+// an empty list and a type-mismatched element are rejected at save.
+func TestInlineINList(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "inlineset",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opIN, Val: []any{25.0, 50.0, 75.0}}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	actionSet, _, err := doMatch(inventoryEntity(), rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 1 {
+		t.Errorf("listed value did not match: %+v", actionSet)
+	}
+
+	entity := inventoryEntity()
+	entity.Attrs["mrp"] = "60"
+	actionSet, _, err = doMatch(entity, rs, ActionSet{Properties: map[string]string{}}, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("doMatch: %v", err)
+	}
+	if len(actionSet.Tasks) != 0 {
+		t.Errorf("unlisted value matched: %+v", actionSet)
+	}
+
+	rs.Rules[0].RulePatterns[0].Val = []any{}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("empty membership list accepted at save")
+	}
+	rs.Rules[0].RulePatterns[0].Val = []any{25.0, "fifty"}
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("type-mismatched list element accepted at save")
+	}
+}
+
+// A term targeting an enum attribute must use declared values, whether
+// written inline or through a value-set reference.
+func TestEnumTermValuesDeclared(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "enumtyposet",
+		Rules: []Rule{{
+			RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbok"}},
+			RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+		}},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	err := verifyRuleSet(rs, false)
+	if err == nil || !strings.Contains(err.Error(), "textbok") || !strings.Contains(err.Error(), "cat") {
+		t.Fatalf("typo in enum term not caught: %v", err)
+	}
+
+	if err := RegisterValueSet("catswithtypo", []string{"textbook", "notebok"}); err != nil {
+		t.Fatalf("RegisterValueSet: %v", err)
+	}
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "cat", Op: opIN, Val: "@catswithtypo"}
+	err = verifyRuleSet(rs, false)
+	if err == nil || !strings.Contains(err.Error(), "notebok") {
+		t.Fatalf("typo in referenced value-set not caught: %v", err)
+	}
+
+	rs.Rules[0].RulePatterns[0] = RulePatternTerm{Attr: "cat", Op: opEQ, Val: "textbook"}
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Errorf("declared enum value rejected: %v", err)
+	}
+}
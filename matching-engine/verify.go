@@ -0,0 +1,573 @@
+package crux
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// attrNameRegexp is the shape every attribute, task and property name
+// must take.
+var attrNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validOps is the set of operators legal in a rule pattern term.
+var validOps = map[string]struct{}{
+	opEQ: {}, opNE: {}, opLT: {}, opLE: {}, opGT: {}, opGE: {},
+	opIN: {}, opNOTIN: {}, opMATCH: {}, opCONTAINS: {}, opBETWEEN: {},
+}
+
+// maxDescLen bounds the documentation strings a schema may carry; they
+// are meant for tooltips and generated docs, not essays.
+const maxDescLen = 500
+
+// verifyRuleSchema checks that rs is internally consistent. isWF marks a
+// workflow schema, which must additionally carry the reserved step and
+// stepfailed attributes. It reports the first problem found; use
+// verifyRuleSchemaAll when the caller wants every problem at once.
+func verifyRuleSchema(rs *RuleSchema, isWF bool) error {
+	if errs := verifyRuleSchemaAll(rs, isWF); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// verifyRuleSchemaAll runs every schema check and accumulates all the
+// failures instead of stopping at the first, so an author fixing a
+// schema sees the whole list in one round trip. A check whose failure
+// would cascade (an unknown value type, say) still skips the dependent
+// checks of that attribute.
+func verifyRuleSchemaAll(rs *RuleSchema, isWF bool) []error {
+	if rs.Class == "" {
+		return []error{fmt.Errorf("schema has no class")}
+	}
+	var errs []error
+	if len(rs.Description) > maxDescLen {
+		errs = append(errs, fmt.Errorf("schema for class %q: description exceeds %d characters", rs.Class, maxDescLen))
+	}
+	if len(rs.PatternSchema) == 0 {
+		errs = append(errs, fmt.Errorf("schema for class %q has no pattern schema", rs.Class))
+	}
+	seen := map[string]struct{}{}
+	for i := range rs.PatternSchema {
+		name := rs.PatternSchema[i].Name
+		if _, dup := seen[name]; dup {
+			// A later getAttrSchema would silently pick the first
+			// declaration, so conflicting types must not get that far.
+			errs = append(errs, schemaAttrError(i, "name", "schema for class %q: duplicate attribute name %q", rs.Class, name))
+		}
+		seen[name] = struct{}{}
+		errs = append(errs, verifyAttrSchema(rs, i)...)
+	}
+	errs = append(errs, verifyActionSchemaChecks(rs)...)
+	if err := verifyPipeline(rs); err != nil {
+		errs = append(errs, err)
+	}
+	if isWF {
+		if err := verifyWFSchema(rs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// verifyAttrSchema runs every check on one attribute of the pattern
+// schema, accumulating failures.
+func verifyAttrSchema(rs *RuleSchema, i int) []error {
+	as := &rs.PatternSchema[i]
+	var errs []error
+	if !attrNameRegexp.MatchString(as.Name) {
+		errs = append(errs, schemaAttrError(i, "name", "schema for class %q: invalid attribute name %q", rs.Class, as.Name))
+	}
+	if len(as.Description) > maxDescLen {
+		errs = append(errs, schemaAttrError(i, "description", "schema for class %q: attribute %q: description exceeds %d characters", rs.Class, as.Name, maxDescLen))
+	}
+	switch as.ValType {
+	case typeBool, typeInt, typeFloat, typeStr, typeTS, typeDate:
+	case typeList:
+		if err := verifyElemType(as); err != nil {
+			errs = append(errs, schemaAttrError(i, "elemtype", "schema for class %q: %v", rs.Class, err))
+		}
+	case typeEnum:
+		if len(as.Vals) == 0 {
+			errs = append(errs, schemaAttrError(i, "vals", "schema for class %q: enum attribute %q has no vals", rs.Class, as.Name))
+		}
+		// START is the reserved initial workflow step; it only has
+		// meaning in the step attribute's enum.
+		if _, ok := as.Vals[startStep]; ok && as.Name != stepAttr {
+			errs = append(errs, schemaAttrError(i, "vals", "schema for class %q: enum attribute %q must not include reserved value %q", rs.Class, as.Name, startStep))
+		}
+		if len(as.OrderedVals) > 0 {
+			if len(as.OrderedVals) != len(as.Vals) {
+				errs = append(errs, schemaAttrError(i, "orderedvals", "schema for class %q: enum attribute %q: ordering must rank every value exactly once", rs.Class, as.Name))
+			}
+			ranked := map[string]struct{}{}
+			for _, v := range as.OrderedVals {
+				if _, ok := as.Vals[v]; !ok {
+					errs = append(errs, schemaAttrError(i, "orderedvals", "schema for class %q: enum attribute %q: ordering ranks unknown value %q", rs.Class, as.Name, v))
+				}
+				if _, dup := ranked[v]; dup {
+					errs = append(errs, schemaAttrError(i, "orderedvals", "schema for class %q: enum attribute %q: ordering ranks value %q twice", rs.Class, as.Name, v))
+				}
+				ranked[v] = struct{}{}
+			}
+		}
+	default:
+		// Dependent checks would only cascade off the unknown type.
+		return append(errs, schemaAttrError(i, "valtype", "schema for class %q: attribute %q has unknown type %q", rs.Class, as.Name, as.ValType))
+	}
+	if err := verifyCanonicalRef(as); err != nil {
+		errs = append(errs, schemaAttrError(i, "canonical", "schema for class %q: %v", rs.Class, err))
+	}
+	if as.Aggregate != nil {
+		if err := verifyAggregateSpec(as); err != nil {
+			errs = append(errs, schemaAttrError(i, "aggregate", "schema for class %q: %v", rs.Class, err))
+		}
+	}
+	if as.Child != nil {
+		if err := verifyChildSpec(as); err != nil {
+			errs = append(errs, schemaAttrError(i, "child", "schema for class %q: %v", rs.Class, err))
+		}
+	}
+	if as.Derive != nil {
+		if err := verifyDeriveSpec(rs, as); err != nil {
+			errs = append(errs, schemaAttrError(i, "derive", "schema for class %q: %v", rs.Class, err))
+		}
+	}
+	if as.Default != "" {
+		if err := verifyType(as.Default, as); err != nil {
+			errs = append(errs, schemaAttrError(i, "default", "schema for class %q: default: %v", rs.Class, err))
+		}
+	}
+	if as.MaxAge < 0 {
+		errs = append(errs, schemaAttrError(i, "maxage", "schema for class %q: attribute %q has negative freshness TTL", rs.Class, as.Name))
+	}
+	if as.TSGranularity != "" {
+		if as.ValType != typeTS {
+			errs = append(errs, schemaAttrError(i, "tsgranularity", "schema for class %q: attribute %q declares a granularity but is not a timestamp", rs.Class, as.Name))
+		} else if _, err := granularityDuration(as.TSGranularity); err != nil {
+			errs = append(errs, schemaAttrError(i, "tsgranularity", "schema for class %q: attribute %q: %v", rs.Class, as.Name, err))
+		}
+	}
+	if cond := as.RequiredIf; cond != nil {
+		if as.Optional {
+			errs = append(errs, schemaAttrError(i, "requiredif", "schema for class %q: attribute %q cannot be both optional and conditionally required", rs.Class, as.Name))
+		}
+		condAttr := getAttrSchema(rs, cond.Attr)
+		if condAttr == nil {
+			errs = append(errs, schemaAttrError(i, "requiredif", "schema for class %q: attribute %q conditional on undeclared attribute %q", rs.Class, as.Name, cond.Attr))
+		} else if err := verifyType(cond.Val, condAttr); err != nil {
+			errs = append(errs, schemaAttrError(i, "requiredif", "schema for class %q: attribute %q condition: %v", rs.Class, as.Name, err))
+		}
+	}
+	return errs
+}
+
+// verifyActionSchemaChecks runs every check on the action schema,
+// accumulating failures.
+func verifyActionSchemaChecks(rs *RuleSchema) []error {
+	var errs []error
+	for _, task := range rs.ActionSchema.Tasks {
+		if !attrNameRegexp.MatchString(task) {
+			errs = append(errs, fmt.Errorf("schema for class %q: invalid task name %q", rs.Class, task))
+		}
+	}
+	for task, desc := range rs.ActionSchema.TaskDescs {
+		if !hasTask(&rs.ActionSchema, task) {
+			errs = append(errs, fmt.Errorf("schema for class %q: description for undeclared task %q", rs.Class, task))
+		}
+		if len(desc) > maxDescLen {
+			errs = append(errs, fmt.Errorf("schema for class %q: task %q: description exceeds %d characters", rs.Class, task, maxDescLen))
+		}
+	}
+	for _, prop := range rs.ActionSchema.Properties {
+		if !attrNameRegexp.MatchString(prop) {
+			errs = append(errs, fmt.Errorf("schema for class %q: invalid property name %q", rs.Class, prop))
+		}
+	}
+	for _, task := range rs.ActionSchema.RequiredTasks {
+		if !hasTask(&rs.ActionSchema, task) {
+			errs = append(errs, fmt.Errorf("schema for class %q: required task %q not among declared tasks", rs.Class, task))
+		}
+	}
+	for prop, format := range rs.ActionSchema.PropFormats {
+		if !hasProperty(&rs.ActionSchema, prop) {
+			errs = append(errs, fmt.Errorf("schema for class %q: format declared for undeclared property %q", rs.Class, prop))
+		}
+		if err := verifyPropFormat(format); err != nil {
+			errs = append(errs, fmt.Errorf("schema for class %q: property %q: %v", rs.Class, prop, err))
+		}
+	}
+	for prop := range rs.ActionSchema.PropConstraints {
+		if !hasProperty(&rs.ActionSchema, prop) {
+			errs = append(errs, fmt.Errorf("schema for class %q: constraint declared for undeclared property %q", rs.Class, prop))
+		}
+	}
+	return errs
+}
+
+// verifyWFSchema enforces the extra attributes a workflow schema needs.
+func verifyWFSchema(rs *RuleSchema) error {
+	stepSchema := getAttrSchema(rs, stepAttr)
+	if stepSchema == nil || stepSchema.ValType != typeEnum {
+		return fmt.Errorf("workflow schema for class %q must have an enum attribute %q", rs.Class, stepAttr)
+	}
+	if _, ok := stepSchema.Vals[startStep]; !ok {
+		return fmt.Errorf("workflow schema for class %q: %q attribute must include %q", rs.Class, stepAttr, startStep)
+	}
+	failedSchema := getAttrSchema(rs, stepFailedAttr)
+	if failedSchema == nil || failedSchema.ValType != typeBool {
+		return fmt.Errorf("workflow schema for class %q must have a bool attribute %q", rs.Class, stepFailedAttr)
+	}
+	return nil
+}
+
+// verifyRuleSet checks every rule of rs against the schema of its class:
+// pattern terms must reference declared attributes with legal operators
+// and values, and actions must emit only declared tasks and properties.
+func verifyRuleSet(rs *RuleSet, isWF bool) error {
+	schema := schemaFor(rs)
+	if schema == nil {
+		return fmt.Errorf("no schema found for class %q", rs.Class)
+	}
+	for i := range rs.Rules {
+		if err := verifyRule(rs, schema, i, isWF); err != nil {
+			return err
+		}
+	}
+	return verifyPropConstraints(rs, schema)
+}
+
+// verifyRule runs every check on one rule of the ruleset: threshold
+// ranges, each pattern term, and the actions. Cross-rule checks live in
+// verifyPropConstraints.
+func verifyRule(rs *RuleSet, schema *RuleSchema, i int, isWF bool) error {
+	rule := rs.Rules[i]
+	if rule.MinConfidence < 0 || rule.MinConfidence > 1 {
+		return fmt.Errorf("ruleset %q rule %d: minconfidence %v outside [0,1]", rs.SetName, i, rule.MinConfidence)
+	}
+	if rule.MinTrust < 0 || rule.MinTrust > 1 {
+		return fmt.Errorf("ruleset %q rule %d: mintrust %v outside [0,1]", rs.SetName, i, rule.MinTrust)
+	}
+	for _, term := range rule.RulePatterns {
+		as := getAttrSchema(schema, term.Attr)
+		if as == nil {
+			// Patterns may also test a task or property emitted
+			// earlier in the evaluation; tasks read as bools and
+			// properties as strings.
+			switch {
+			case hasProperty(&schema.ActionSchema, term.Attr):
+				as = &AttrSchema{Name: term.Attr, ValType: typeStr}
+			case hasTask(&schema.ActionSchema, term.Attr):
+				as = &AttrSchema{Name: term.Attr, ValType: typeBool}
+			default:
+				return fmt.Errorf("ruleset %q rule %d: attribute %q not in schema", rs.SetName, i, term.Attr)
+			}
+		}
+		if term.CaseInsensitive && as.ValType != typeStr && as.ValType != typeEnum {
+			return fmt.Errorf("ruleset %q rule %d: caseinsensitive not valid for %s attribute %q", rs.SetName, i, as.ValType, term.Attr)
+		}
+		if term.CaseInsensitive && term.Op == opMATCH {
+			// Lower-casing a regex corrupts its pattern text; authors
+			// wanting case-blind matching prefix the pattern with (?i).
+			return fmt.Errorf("ruleset %q rule %d: caseinsensitive not valid with op %q; use an (?i) pattern instead", rs.SetName, i, term.Op)
+		}
+		if _, ok := validOps[term.Op]; !ok {
+			op, custom := realmOp(rs.Realm, term.Op)
+			if !custom {
+				return fmt.Errorf("ruleset %q rule %d: unknown operator %q", rs.SetName, i, term.Op)
+			}
+			if op.Validate != nil {
+				if err := op.Validate(&term, as); err != nil {
+					return fmt.Errorf("ruleset %q rule %d: %v", rs.SetName, i, err)
+				}
+			}
+			continue // the custom operator owns its value semantics
+		}
+		if term.Op == opIN || term.Op == opNOTIN {
+			if err := verifySetTerm(rs, i, &term, as); err != nil {
+				return err
+			}
+			continue
+		}
+		if term.Op == opMATCH {
+			if err := verifyRegexTerm(rs, i, &term, as); err != nil {
+				return err
+			}
+			continue
+		}
+		if term.Op == opCONTAINS {
+			if err := verifyContainsTerm(rs, i, &term, as); err != nil {
+				return err
+			}
+			continue
+		}
+		if term.Op == opBETWEEN {
+			if err := verifyBetweenTerm(rs, i, &term, as); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := verifyTermVal(rs, i, &term, as); err != nil {
+			return err
+		}
+	}
+	return verifyRuleActions(rs, i, &rule.RuleActions, schema, isWF)
+}
+
+// verifyPropConstraints enforces the schema's PropConstraints across the
+// whole ruleset: every value a rule sets must be among the allowed ones,
+// and a single-valued property may be set by at most one rule.
+func verifyPropConstraints(rs *RuleSet, schema *RuleSchema) error {
+	setters := map[string]int{}
+	for i := range rs.Rules {
+		for prop, val := range rs.Rules[i].RuleActions.Properties {
+			con, ok := schema.ActionSchema.PropConstraints[prop]
+			if !ok {
+				continue
+			}
+			if len(con.Vals) > 0 && !containsString(con.Vals, val) {
+				return fmt.Errorf("ruleset %q rule %d: property %q set to disallowed value %q", rs.SetName, i, prop, val)
+			}
+			setters[prop]++
+			if con.Single && setters[prop] > 1 {
+				return fmt.Errorf("ruleset %q rule %d: single-valued property %q set by more than one rule", rs.SetName, i, prop)
+			}
+		}
+	}
+	return nil
+}
+
+// containsString reports whether vals contains s.
+func containsString(vals []string, s string) bool {
+	for _, v := range vals {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyTermVal checks that a pattern term's value is the right type for
+// the attribute it tests, and that ordering operators are not applied to
+// unordered types.
+func verifyTermVal(rs *RuleSet, ruleIdx int, term *RulePatternTerm, as *AttrSchema) error {
+	ordered := term.Op == opLT || term.Op == opLE || term.Op == opGT || term.Op == opGE
+	switch as.ValType {
+	case typeBool:
+		if _, ok := term.Val.(bool); !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a bool value", rs.SetName, ruleIdx, term.Attr)
+		}
+		if ordered {
+			return fmt.Errorf("ruleset %q rule %d: operator %q not valid for bool attribute %q", rs.SetName, ruleIdx, term.Op, term.Attr)
+		}
+	case typeInt:
+		switch v := term.Val.(type) {
+		case int, float64:
+		case json.Number:
+			if _, err := v.Float64(); err != nil {
+				return fmt.Errorf("ruleset %q rule %d: attribute %q needs a numeric value", rs.SetName, ruleIdx, term.Attr)
+			}
+		default:
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a numeric value", rs.SetName, ruleIdx, term.Attr)
+		}
+	case typeFloat:
+		switch v := term.Val.(type) {
+		case float64, int:
+		case json.Number:
+			if _, err := v.Float64(); err != nil {
+				return fmt.Errorf("ruleset %q rule %d: attribute %q needs a numeric value", rs.SetName, ruleIdx, term.Attr)
+			}
+		default:
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a numeric value", rs.SetName, ruleIdx, term.Attr)
+		}
+	case typeStr:
+		if _, ok := term.Val.(string); !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a string value", rs.SetName, ruleIdx, term.Attr)
+		}
+	case typeEnum:
+		s, ok := term.Val.(string)
+		if !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a string value", rs.SetName, ruleIdx, term.Attr)
+		}
+		if _, ok := as.Vals[s]; !ok {
+			return fmt.Errorf("ruleset %q rule %d: %q is not a permitted value for attribute %q", rs.SetName, ruleIdx, s, term.Attr)
+		}
+		if ordered && len(as.OrderedVals) == 0 {
+			return fmt.Errorf("ruleset %q rule %d: operator %q not valid for unordered enum attribute %q", rs.SetName, ruleIdx, term.Op, term.Attr)
+		}
+	case typeTS:
+		s, ok := term.Val.(string)
+		if !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a timestamp string", rs.SetName, ruleIdx, term.Attr)
+		}
+		if _, err := convertEntityAttrVal(s, typeTS, nil); err != nil {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q: %v", rs.SetName, ruleIdx, term.Attr, err)
+		}
+	case typeDate:
+		s, ok := term.Val.(string)
+		if !ok {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q needs a date string", rs.SetName, ruleIdx, term.Attr)
+		}
+		if _, err := convertEntityAttrVal(s, typeDate, nil); err != nil {
+			return fmt.Errorf("ruleset %q rule %d: attribute %q: %q is not a %s date", rs.SetName, ruleIdx, term.Attr, s, dateLayout)
+		}
+	case typeList:
+		return fmt.Errorf("ruleset %q rule %d: operator %q not valid for list attribute %q", rs.SetName, ruleIdx, term.Op, term.Attr)
+	}
+	return nil
+}
+
+// verifyRuleActions checks the action block of one rule against the
+// action schema of its class.
+func verifyRuleActions(rs *RuleSet, ruleIdx int, ra *RuleActions, schema *RuleSchema, isWF bool) error {
+	if ra.UseMacro != "" {
+		if _, ok := actionMacros[ra.UseMacro]; !ok {
+			return fmt.Errorf("ruleset %q rule %d: unknown action macro %q", rs.SetName, ruleIdx, ra.UseMacro)
+		}
+	}
+	for _, task := range ra.Tasks {
+		if !hasTask(&schema.ActionSchema, task) {
+			return fmt.Errorf("ruleset %q rule %d: task %q not in action schema", rs.SetName, ruleIdx, task)
+		}
+	}
+	for prop := range ra.Properties {
+		if !hasProperty(&schema.ActionSchema, prop) {
+			return fmt.Errorf("ruleset %q rule %d: property %q not in action schema", rs.SetName, ruleIdx, prop)
+		}
+	}
+	if ra.Enrich != "" {
+		if _, ok := enrichmentFuncs[ra.Enrich]; !ok {
+			return fmt.Errorf("ruleset %q rule %d: enrichment function %q not registered", rs.SetName, ruleIdx, ra.Enrich)
+		}
+	}
+	if ra.WillReturn && ra.WillExit {
+		return fmt.Errorf("ruleset %q rule %d: both RETURN and EXIT set", rs.SetName, ruleIdx)
+	}
+	if isWF {
+		if _, ok := ra.Properties[nextStepProp]; ok && len(ra.Tasks) == 0 {
+			return fmt.Errorf("ruleset %q rule %d: %s set without any tasks", rs.SetName, ruleIdx, nextStepProp)
+		}
+	}
+	return nil
+}
+
+// EntityCheckMode selects how verifyEntity treats attributes an entity
+// carries that its schema does not declare.
+type EntityCheckMode int
+
+const (
+	// EntityCheckStrict rejects entities with undeclared attributes.
+	EntityCheckStrict EntityCheckMode = iota
+	// EntityCheckLenient ignores undeclared attributes, validating only
+	// the declared ones. This keeps evaluation forward-compatible when
+	// producers add fields before the schema catches up.
+	EntityCheckLenient
+)
+
+// realmEntityCheckModes holds per-realm defaults for the entity check
+// mode; realms absent from the map are strict.
+var realmEntityCheckModes = map[string]EntityCheckMode{}
+
+// SetRealmEntityCheckMode sets the default entity check mode for a realm.
+func SetRealmEntityCheckMode(realm string, mode EntityCheckMode) {
+	realmEntityCheckModes[realm] = mode
+}
+
+// UnknownAttrHook, when non-nil, is called once per undeclared attribute
+// ignored in lenient mode, so callers can log or count them.
+var UnknownAttrHook func(class, attr string)
+
+// verifyEntity checks an entity against the schema of its class using
+// the check mode configured for the entity's realm (strict by default).
+func verifyEntity(e Entity) error {
+	return verifyEntityWithMode(e, realmEntityCheckModes[e.Realm])
+}
+
+// verifyEntityWithMode checks an entity against the schema of its class.
+// Every declared attribute must be present with a value passing the
+// attribute's constraints; undeclared attributes are rejected or ignored
+// per mode.
+func verifyEntityWithMode(e Entity, mode EntityCheckMode) error {
+	schema := scopedRuleSchema(e.App, e.Class)
+	if schema == nil {
+		if unknownClassPolicy == UnknownClassPassthrough {
+			// Schema-less evaluation: there is nothing to check the
+			// entity against; doMatch applies the passthrough rules.
+			return nil
+		}
+		return fmt.Errorf("no schema found for class %q", e.Class)
+	}
+	for name, prov := range e.Provenance {
+		if _, ok := e.Attrs[name]; !ok {
+			return attrError(name, "entity of class %q: provenance given for absent attribute %q", e.Class, name)
+		}
+		if prov.Source == "" {
+			return attrError(name, "entity of class %q: provenance for %q has no source", e.Class, name)
+		}
+		if prov.Trust < 0 || prov.Trust > 1 {
+			return attrError(name, "entity of class %q: provenance trust for %q outside [0,1]", e.Class, name)
+		}
+	}
+	for name, val := range e.Attrs {
+		as := getAttrSchema(schema, name)
+		if as == nil {
+			if mode == EntityCheckLenient {
+				if UnknownAttrHook != nil {
+					UnknownAttrHook(e.Class, name)
+				}
+				continue
+			}
+			return attrError(name, "entity of class %q: attribute %q not in schema", e.Class, name)
+		}
+		if err := verifyType(val, as); err != nil {
+			return attrError(name, "entity of class %q: %v", e.Class, err)
+		}
+	}
+	for i := range schema.PatternSchema {
+		as := &schema.PatternSchema[i]
+		if _, ok := e.Attrs[as.Name]; ok {
+			continue
+		}
+		if as.Aggregate != nil {
+			continue // computed from the event window, not carried as an attribute
+		}
+		if as.Child != nil {
+			continue // computed from the child collection, not carried as an attribute
+		}
+		if as.Optional {
+			continue // declared omittable; rules referencing it simply fail to match
+		}
+		if as.Default != "" {
+			continue // the declared default stands in; see verifyAndConvertEntity
+		}
+		if cond := as.RequiredIf; cond != nil {
+			if e.Attrs[cond.Attr] != cond.Val {
+				continue // condition not met, attribute may be absent
+			}
+			return attrError(as.Name, "entity of class %q is missing attribute %q, required when %s=%s", e.Class, as.Name, cond.Attr, cond.Val)
+		}
+		return attrError(as.Name, "entity of class %q is missing attribute %q", e.Class, as.Name)
+	}
+	return nil
+}
+
+// hasTask reports whether the action schema declares the named task.
+func hasTask(as *ActionSchema, task string) bool {
+	for _, t := range as.Tasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProperty reports whether the action schema declares the named
+// property.
+func hasProperty(as *ActionSchema, prop string) bool {
+	for _, p := range as.Properties {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyEntityLenientMode(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	e := inventoryEntity()
+	e.Attrs["fulfilmenthub"] = "west2" // not in the schema
+
+	if err := verifyEntityWithMode(e, EntityCheckStrict); err == nil {
+		t.Errorf("strict mode accepted entity with unknown attribute")
+	}
+
+	var ignored []string
+	UnknownAttrHook = func(class, attr string) { ignored = append(ignored, attr) }
+	defer func() { UnknownAttrHook = nil }()
+	if err := verifyEntityWithMode(e, EntityCheckLenient); err != nil {
+		t.Errorf("lenient mode rejected entity with unknown attribute: %v", err)
+	}
+	if len(ignored) != 1 || ignored[0] != "fulfilmenthub" {
+		t.Errorf("UnknownAttrHook saw %v, want [fulfilmenthub]", ignored)
+	}
+
+	// Lenient mode still validates declared attributes.
+	e.Attrs["mrp"] = "not-a-number"
+	if err := verifyEntityWithMode(e, EntityCheckLenient); err == nil {
+		t.Errorf("lenient mode accepted bad value for declared attribute")
+	}
+}
+
+func TestVerifyEntityRealmMode(t *testing.T) {
+	defer setupTestSchema(t, inventorySchema(), nil)()
+	defer func() { delete(realmEntityCheckModes, "acme") }()
+	e := inventoryEntity()
+	e.Realm = "acme"
+	e.Attrs["fulfilmenthub"] = "west2"
+
+	if err := verifyEntity(e); err == nil {
+		t.Errorf("realm without lenient override accepted unknown attribute")
+	}
+	SetRealmEntityCheckMode("acme", EntityCheckLenient)
+	if err := verifyEntity(e); err != nil {
+		t.Errorf("lenient realm rejected unknown attribute: %v", err)
+	}
+}
+
+func TestStartReservedForStepEnum(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema[0].Vals[startStep] = struct{}{} // "cat" enum
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted START in non-step enum")
+	}
+
+	// START in the step attribute's enum stays legal.
+	if err := verifyRuleSchema(wfOrderSchema(), true); err != nil {
+		t.Errorf("verifyRuleSchema rejected START in step enum: %v", err)
+	}
+}
+
+func TestConditionallyRequiredAttr(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "claim",
+		PatternSchema: []AttrSchema{
+			{Name: "status", ValType: typeEnum, Vals: map[string]struct{}{"approved": {}, "rejected": {}}},
+			{Name: "rejectionreason", ValType: typeStr,
+				RequiredIf: &AttrCondition{Attr: "status", Val: "rejected"}},
+		},
+	}
+	defer setupTestSchema(t, schema, nil)()
+	if err := verifyRuleSchema(schema, false); err != nil {
+		t.Fatalf("verifyRuleSchema: %v", err)
+	}
+
+	// Condition met and attribute present: valid.
+	e := Entity{Class: "claim", Attrs: map[string]string{"status": "rejected", "rejectionreason": "duplicate"}}
+	if err := verifyEntity(e); err != nil {
+		t.Errorf("rejected claim with reason failed: %v", err)
+	}
+
+	// Condition met but attribute missing: precise error.
+	e = Entity{Class: "claim", Attrs: map[string]string{"status": "rejected"}}
+	err := verifyEntity(e)
+	if err == nil {
+		t.Fatalf("rejected claim without reason passed")
+	}
+	if !strings.Contains(err.Error(), "required when status=rejected") {
+		t.Errorf("error %q does not explain the condition", err)
+	}
+
+	// Condition not met: attribute may be absent.
+	e = Entity{Class: "claim", Attrs: map[string]string{"status": "approved"}}
+	if err := verifyEntity(e); err != nil {
+		t.Errorf("approved claim without reason failed: %v", err)
+	}
+}
+
+func TestConditionValidatedAtSave(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "claim",
+		PatternSchema: []AttrSchema{
+			{Name: "reason", ValType: typeStr,
+				RequiredIf: &AttrCondition{Attr: "nosuch", Val: "x"}},
+		},
+	}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("verifyRuleSchema accepted condition on undeclared attribute")
+	}
+}
+
+// Property constraints in the action schema reject values outside the
+// allowed set and a single-valued property set by two rules.
+func TestPropConstraints(t *testing.T) {
+	schema := inventorySchema()
+	schema.ActionSchema.PropConstraints = map[string]PropConstraint{
+		"shipby": {Vals: []string{"air", "road"}, Single: true},
+	}
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "constrainedset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Properties: map[string]string{"shipby": "air"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, rs)
+	defer teardown()
+
+	if err := verifyRuleSet(rs, false); err != nil {
+		t.Fatalf("conforming ruleset rejected: %v", err)
+	}
+
+	rs.Rules[0].RuleActions.Properties["shipby"] = "carrierpigeon"
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("disallowed property value accepted")
+	}
+	rs.Rules[0].RuleActions.Properties["shipby"] = "air"
+
+	rs.Rules = append(rs.Rules, Rule{
+		RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+		RuleActions:  RuleActions{Properties: map[string]string{"shipby": "road"}},
+	})
+	if err := verifyRuleSet(rs, false); err == nil {
+		t.Fatalf("single-valued property set by two rules accepted")
+	}
+
+	schema.ActionSchema.PropConstraints["undeclared"] = PropConstraint{Single: true}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Fatalf("constraint on undeclared property accepted")
+	}
+}
+
+// Documentation fields round-trip through save and get untouched, and
+// oversized or misplaced descriptions are rejected at save.
+func TestSchemaDescriptionsRoundTrip(t *testing.T) {
+	teardown := setupTestSchema(t, inventorySchema(), nil)
+	defer teardown()
+	ruleSchemas = nil
+	invalidateSchemaIndex()
+
+	schema := inventorySchema()
+	schema.Description = "Inventory items stocked across stores"
+	schema.PatternSchema[0].Description = "Product category"
+	schema.ActionSchema.TaskDescs = map[string]string{"invitefordiwali": "Send the festive mailer"}
+	if err := SaveRuleSchema(schema, false); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+
+	got, ok := GetSchema("inventoryitems")
+	if !ok {
+		t.Fatalf("saved schema not found")
+	}
+	if got.Description != schema.Description {
+		t.Errorf("class description lost: %q", got.Description)
+	}
+	if got.PatternSchema[0].Description != "Product category" {
+		t.Errorf("attribute description lost: %q", got.PatternSchema[0].Description)
+	}
+	if got.ActionSchema.TaskDescs["invitefordiwali"] != "Send the festive mailer" {
+		t.Errorf("task description lost: %q", got.ActionSchema.TaskDescs["invitefordiwali"])
+	}
+
+	long := make([]byte, maxDescLen+1)
+	for i := range long {
+		long[i] = 'x'
+	}
+	oversized := inventorySchema()
+	oversized.Description = string(long)
+	if err := SaveRuleSchema(oversized, false); err == nil {
+		t.Errorf("oversized class description accepted")
+	}
+	misplaced := inventorySchema()
+	misplaced.ActionSchema.TaskDescs = map[string]string{"nosuchtask": "orphan"}
+	if err := SaveRuleSchema(misplaced, false); err == nil {
+		t.Errorf("description for undeclared task accepted")
+	}
+}
+
+// A schema with several independent defects reports all of them in one
+// pass, and verifyRuleSchema still returns just the first.
+func TestVerifyRuleSchemaAll(t *testing.T) {
+	schema := &RuleSchema{
+		Class: "inventoryitems",
+		PatternSchema: []AttrSchema{
+			{Name: "Bad Name", ValType: typeStr},
+			{Name: "cat", ValType: "decimal"},
+			{Name: "mrp", ValType: typeFloat, MaxAge: -1},
+		},
+		ActionSchema: ActionSchema{
+			Tasks:         []string{"invitefordiwali"},
+			RequiredTasks: []string{"nosuchtask"},
+		},
+	}
+	errs := verifyRuleSchemaAll(schema, false)
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	first := verifyRuleSchema(schema, false)
+	if first == nil || first.Error() != errs[0].Error() {
+		t.Errorf("verifyRuleSchema did not return the first accumulated error: %v", first)
+	}
+}
+
+// Two attributes with the same name, or an enum ordering that ranks a
+// value twice, fail verification instead of silently resolving to
+// whichever declaration comes first.
+func TestDuplicateSchemaNames(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema,
+		AttrSchema{Name: "mrp", ValType: typeInt})
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("duplicate attribute name accepted")
+	}
+
+	schema = inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{
+		Name:        "grade",
+		ValType:     typeEnum,
+		Vals:        map[string]struct{}{"a": {}, "b": {}},
+		OrderedVals: []string{"a", "a"},
+	})
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("duplicate enum ordering entry accepted")
+	}
+}
+
+// An optional attribute may be omitted outright, while unknown
+// attributes are still rejected; required attributes stay required by
+// default. An attribute cannot be optional and conditionally required
+// at once.
+func TestOptionalAttr(t *testing.T) {
+	schema := inventorySchema()
+	schema.PatternSchema = append(schema.PatternSchema, AttrSchema{Name: "batchno", ValType: typeStr, Optional: true})
+	teardown := setupTestSchema(t, schema, nil)
+	defer teardown()
+
+	entity := inventoryEntity() // has no batchno
+	if err := verifyEntity(entity); err != nil {
+		t.Errorf("entity omitting an optional attribute rejected: %v", err)
+	}
+	entity.Attrs["bogus"] = "1"
+	if err := verifyEntity(entity); err == nil {
+		t.Errorf("unknown attribute accepted alongside optional marker")
+	}
+	delete(entity.Attrs, "bogus")
+	delete(entity.Attrs, "mrp")
+	if err := verifyEntity(entity); err == nil {
+		t.Errorf("required attribute mrp no longer enforced")
+	}
+
+	schema.PatternSchema[len(schema.PatternSchema)-1].RequiredIf = &AttrCondition{Attr: "cat", Val: "textbook"}
+	if err := verifyRuleSchema(schema, false); err == nil {
+		t.Errorf("optional + requiredif accepted at save")
+	}
+}
@@ -0,0 +1,33 @@
+package crux
+
+// VerifyFailure names one stored schema or ruleset that no longer passes
+// verification, typically because the verifier has grown stricter since
+// the object was saved.
+type VerifyFailure struct {
+	Kind string `json:"kind"` // "schema" or "ruleset"
+	Name string `json:"name"` // class for schemas, set name for rulesets
+	Err  string `json:"err"`
+}
+
+// VerifyAll re-runs full verification over every loaded schema and every
+// loaded ruleset of the realm (all realms when realm is empty), so
+// operators can confirm stored objects still verify before an engine
+// upgrade goes live. It returns one entry per failing object; an empty
+// result means everything still passes.
+func VerifyAll(realm string) []VerifyFailure {
+	var failures []VerifyFailure
+	for _, schema := range ruleSchemas {
+		if err := verifyRuleSchema(schema, false); err != nil {
+			failures = append(failures, VerifyFailure{Kind: "schema", Name: schema.Class, Err: err.Error()})
+		}
+	}
+	for name, rs := range ruleSets {
+		if realm != "" && rs.Realm != realm {
+			continue
+		}
+		if err := verifyRuleSet(rs, false); err != nil {
+			failures = append(failures, VerifyFailure{Kind: "ruleset", Name: name, Err: err.Error()})
+		}
+	}
+	return failures
+}
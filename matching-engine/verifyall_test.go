@@ -0,0 +1,49 @@
+package crux
+
+import "testing"
+
+// A ruleset saved before a stricter verifier shipped may violate checks
+// it never faced; VerifyAll must surface it without touching rulesets of
+// other realms.
+func TestVerifyAllFlagsStaleRuleset(t *testing.T) {
+	schema := inventorySchema()
+	// The constraint arrived after the ruleset below was stored.
+	schema.ActionSchema.PropConstraints = map[string]PropConstraint{
+		"shipby": {Vals: []string{"air", "road"}},
+	}
+	stale := &RuleSet{
+		Id:      1,
+		Realm:   "acme",
+		Class:   "inventoryitems",
+		SetName: "staleset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Properties: map[string]string{"shipby": "sea"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, schema, stale)
+	defer teardown()
+	ruleSets["healthyset"] = &RuleSet{
+		Id:      2,
+		Realm:   "acme",
+		Class:   "inventoryitems",
+		SetName: "healthyset",
+		Rules: []Rule{
+			{RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}}},
+		},
+	}
+
+	failures := VerifyAll("acme")
+	if len(failures) != 1 {
+		t.Fatalf("got %d failures, want 1: %+v", len(failures), failures)
+	}
+	if failures[0].Kind != "ruleset" || failures[0].Name != "staleset" || failures[0].Err == "" {
+		t.Fatalf("unexpected failure report: %+v", failures[0])
+	}
+
+	if failures := VerifyAll("otherrealm"); len(failures) != 0 {
+		t.Fatalf("another realm's report includes acme's ruleset: %+v", failures)
+	}
+}
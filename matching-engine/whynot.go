@@ -0,0 +1,132 @@
+package crux
+
+import "fmt"
+
+// WhyNot explains, for one rule that could have emitted an expected
+// task, why the task did not fire: the rule's pattern failed (with the
+// failing terms), a confidence or trust floor blocked it, or evaluation
+// never reached it because an earlier rule returned or exited.
+type WhyNot struct {
+	RuleIdx     int        `json:"ruleidx"`
+	Reason      string     `json:"reason"`
+	FailedTerms []NearMiss `json:"failedterms,omitempty"`
+}
+
+// WhyNotTask answers "why didn't the expected task fire for this
+// entity?" against a loaded ruleset, verifying the entity first. It is
+// the exported form of whyNotTask for API consumers and diagnostic
+// endpoints.
+func WhyNotTask(setName string, entity Entity, task string) ([]WhyNot, error) {
+	rs, ok := ruleSets[setName]
+	if !ok {
+		return nil, fmt.Errorf("ruleset %q not found", setName)
+	}
+	entity, err := verifyAndConvertEntity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return whyNotTask(entity, rs, task)
+}
+
+// whyNotTask answers "why didn't task X fire for this entity?": it finds
+// every rule of the ruleset whose actions include the task and reports
+// why each one failed to contribute it. Like nearMisses it inspects one
+// ruleset at a time; rules reached only through THENCALL/ELSECALL are
+// out of scope.
+func whyNotTask(entity Entity, rs *RuleSet, task string) ([]WhyNot, error) {
+	schema := schemaFor(rs)
+	if schema == nil {
+		return nil, fmt.Errorf("no schema found for class %q", rs.Class)
+	}
+	if !hasTask(&schema.ActionSchema, task) {
+		return nil, fmt.Errorf("task %q not declared in the schema for class %q", task, rs.Class)
+	}
+	candidates := 0
+	actionSet := ActionSet{Properties: map[string]string{}}
+	stoppedAt := -1
+	var report []WhyNot
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		emits := false
+		for _, t := range rule.RuleActions.Tasks {
+			if t == task {
+				emits = true
+			}
+		}
+		if emits {
+			candidates++
+		}
+		if emits && stoppedAt >= 0 {
+			report = append(report, WhyNot{
+				RuleIdx: i,
+				Reason:  fmt.Sprintf("unreached: evaluation stopped at rule %d", stoppedAt),
+			})
+			continue
+		}
+		matched, quality, err := matchPattern(entity, rule.RulePatterns, actionSet, schema)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case matched && rule.MinConfidence > 0 && quality.confidence < rule.MinConfidence:
+			if emits {
+				report = append(report, WhyNot{
+					RuleIdx: i,
+					Reason:  fmt.Sprintf("pattern held but confidence %.2f is below the rule's floor of %.2f", quality.confidence, rule.MinConfidence),
+				})
+			}
+		case matched && rule.MinTrust > 0 && quality.trust < rule.MinTrust:
+			if emits {
+				report = append(report, WhyNot{
+					RuleIdx: i,
+					Reason:  fmt.Sprintf("pattern held but trust %.2f is below the rule's floor of %.2f", quality.trust, rule.MinTrust),
+				})
+			}
+		case matched:
+			if rule.RuleActions.WillReturn || rule.RuleActions.WillExit {
+				stoppedAt = i
+			}
+		default:
+			if emits {
+				failed, err := failedTerms(entity, rule, actionSet, schema, i)
+				if err != nil {
+					return nil, err
+				}
+				report = append(report, WhyNot{
+					RuleIdx:     i,
+					Reason:      "pattern did not match",
+					FailedTerms: failed,
+				})
+			}
+		}
+	}
+	if candidates == 0 {
+		return nil, fmt.Errorf("no rule in ruleset %q emits task %q", rs.SetName, task)
+	}
+	return report, nil
+}
+
+// failedTerms evaluates each term of a non-matching rule on its own and
+// returns the ones that did not hold, with the actual value seen.
+func failedTerms(entity Entity, rule *Rule, actionSet ActionSet, schema *RuleSchema, ruleIdx int) ([]NearMiss, error) {
+	var failed []NearMiss
+	for j := range rule.RulePatterns {
+		term := rule.RulePatterns[j : j+1]
+		holds, _, err := matchPattern(entity, term, actionSet, schema)
+		if err != nil {
+			return nil, err
+		}
+		if holds {
+			continue
+		}
+		actual, _ := getEntityAttrVal(entity, actionSet, term[0].Attr)
+		failed = append(failed, NearMiss{
+			RuleIdx:  ruleIdx,
+			Attr:     term[0].Attr,
+			Op:       term[0].Op,
+			Expected: term[0].Val,
+			Actual:   actual,
+		})
+	}
+	return failed, nil
+}
@@ -0,0 +1,56 @@
+package crux
+
+import (
+	"strings"
+	"testing"
+)
+
+// The "why not" report explains every rule that could have emitted the
+// expected task: a pattern failure with the blocking term, and a rule
+// left unreached by an earlier RETURN.
+func TestWhyNotExpectedTask(t *testing.T) {
+	rs := &RuleSet{
+		Id:      1,
+		Class:   "inventoryitems",
+		SetName: "whynotset",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "mrp", Op: opGT, Val: 100.0}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "textbook"}},
+				RuleActions:  RuleActions{Properties: map[string]string{"discount": "5"}, WillReturn: true},
+			},
+			{
+				RulePatterns: []RulePatternTerm{{Attr: "cat", Op: opEQ, Val: "notebook"}},
+				RuleActions:  RuleActions{Tasks: []string{"invitefordiwali"}},
+			},
+		},
+	}
+	teardown := setupTestSchema(t, inventorySchema(), rs)
+	defer teardown()
+
+	report, err := WhyNotTask("whynotset", inventoryEntity(), "invitefordiwali")
+	if err != nil {
+		t.Fatalf("WhyNotTask: %v", err)
+	}
+	if len(report) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", report)
+	}
+	first := report[0]
+	if first.RuleIdx != 0 || first.Reason != "pattern did not match" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if len(first.FailedTerms) != 1 || first.FailedTerms[0].Attr != "mrp" || first.FailedTerms[0].Actual != "50" {
+		t.Errorf("unexpected failing terms: %+v", first.FailedTerms)
+	}
+	second := report[1]
+	if second.RuleIdx != 2 || !strings.Contains(second.Reason, "stopped at rule 1") {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+
+	if _, err := WhyNotTask("whynotset", inventoryEntity(), "nosuchtask"); err == nil {
+		t.Errorf("undeclared expected task accepted")
+	}
+}
@@ -0,0 +1,144 @@
+package crux
+
+import "sort"
+
+// WorkflowReport is the consolidated result of
+// VerifyWorkflowCompleteness: everything that would make a workflow
+// misbehave in production, gathered in one pass so CI can gate on it.
+type WorkflowReport struct {
+	UnreachableSteps  []string `json:"unreachable_steps"`   // declared steps no transition leads to
+	DeadEndSteps      []string `json:"dead_end_steps"`      // reachable steps from which no path sets done=true
+	CyclesWithoutExit []string `json:"cycles_without_exit"` // steps on a cycle that cannot terminate
+	UnusedSteps       []string `json:"unused_steps"`        // declared steps never guarded on or targeted
+	UnusedTasks       []string `json:"unused_tasks"`        // declared tasks no rule emits
+}
+
+// OK reports whether the workflow passed every completeness check.
+func (r *WorkflowReport) OK() bool {
+	return len(r.UnreachableSteps) == 0 && len(r.DeadEndSteps) == 0 &&
+		len(r.CyclesWithoutExit) == 0 && len(r.UnusedSteps) == 0 && len(r.UnusedTasks) == 0
+}
+
+// VerifyWorkflowCompleteness runs the workflow graph checks: every step
+// must be reachable from START, every reachable step must have a path to
+// a rule that sets done=true, cycles that cannot exit are flagged, and
+// declared steps and tasks must actually be used. Run it before
+// promoting a workflow.
+func VerifyWorkflowCompleteness(rs RuleSet, schema RuleSchema) *WorkflowReport {
+	report := &WorkflowReport{}
+	stepSchema := getAttrSchema(&schema, stepAttr)
+	if stepSchema == nil {
+		return report
+	}
+
+	edges := map[string][]string{}
+	terminating := map[string]bool{}
+	guarded := map[string]bool{}
+	targeted := map[string]bool{}
+	usedTasks := map[string]bool{}
+	for i := range rs.Rules {
+		step, ok := ruleStep(rs.Rules[i].RulePatterns)
+		if !ok {
+			continue
+		}
+		guarded[step] = true
+		props := rs.Rules[i].RuleActions.Properties
+		if next, ok := props[nextStepProp]; ok {
+			edges[step] = append(edges[step], next)
+			targeted[next] = true
+		}
+		if props[doneProp] == "true" {
+			terminating[step] = true
+		}
+		for _, task := range rs.Rules[i].RuleActions.Tasks {
+			usedTasks[task] = true
+		}
+	}
+
+	// Forward reachability from START.
+	reachable := map[string]bool{}
+	stack := []string{startStep}
+	for len(stack) > 0 {
+		step := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[step] {
+			continue
+		}
+		reachable[step] = true
+		stack = append(stack, edges[step]...)
+	}
+
+	// Backward reachability from terminating steps.
+	reverse := map[string][]string{}
+	for from, tos := range edges {
+		for _, to := range tos {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	canFinish := map[string]bool{}
+	for step := range terminating {
+		stack = append(stack, step)
+	}
+	for len(stack) > 0 {
+		step := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if canFinish[step] {
+			continue
+		}
+		canFinish[step] = true
+		stack = append(stack, reverse[step]...)
+	}
+
+	for step := range stepSchema.Vals {
+		switch {
+		case step != startStep && !reachable[step] && (guarded[step] || targeted[step]):
+			report.UnreachableSteps = append(report.UnreachableSteps, step)
+		case reachable[step] && !canFinish[step]:
+			report.DeadEndSteps = append(report.DeadEndSteps, step)
+		}
+		if !guarded[step] && !targeted[step] && step != startStep {
+			report.UnusedSteps = append(report.UnusedSteps, step)
+		}
+	}
+
+	// A dead-end step that can loop back to itself is a cycle with no
+	// way out.
+	for _, step := range report.DeadEndSteps {
+		if onCycle(step, edges) {
+			report.CyclesWithoutExit = append(report.CyclesWithoutExit, step)
+		}
+	}
+
+	for _, task := range schema.ActionSchema.Tasks {
+		if !usedTasks[task] {
+			report.UnusedTasks = append(report.UnusedTasks, task)
+		}
+	}
+
+	sort.Strings(report.UnreachableSteps)
+	sort.Strings(report.DeadEndSteps)
+	sort.Strings(report.CyclesWithoutExit)
+	sort.Strings(report.UnusedSteps)
+	sort.Strings(report.UnusedTasks)
+	return report
+}
+
+// onCycle reports whether a step can reach itself through the
+// transition graph.
+func onCycle(start string, edges map[string][]string) bool {
+	seen := map[string]bool{}
+	stack := append([]string{}, edges[start]...)
+	for len(stack) > 0 {
+		step := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if step == start {
+			return true
+		}
+		if seen[step] {
+			continue
+		}
+		seen[step] = true
+		stack = append(stack, edges[step]...)
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package crux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyWorkflowCompletenessDeadEnd(t *testing.T) {
+	schema := wfOrderSchema()
+	defer setupTestSchema(t, schema, nil)()
+	rs := RuleSet{
+		Class:   "order",
+		SetName: "orderflow",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: startStep}},
+				RuleActions:  RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{nextStepProp: "approve"}},
+			},
+			{
+				// approve transitions to ship, but no ship rule ever
+				// sets done=true: ship is a dead end.
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "approve"}},
+				RuleActions:  RuleActions{Tasks: []string{"ship"}, Properties: map[string]string{nextStepProp: "ship"}},
+			},
+		},
+	}
+
+	report := VerifyWorkflowCompleteness(rs, *schema)
+	if report.OK() {
+		t.Fatalf("report.OK() for workflow with dead end")
+	}
+	if !reflect.DeepEqual(report.DeadEndSteps, []string{"START", "approve", "ship"}) {
+		t.Errorf("DeadEndSteps = %v, want the whole path flagged while nothing terminates", report.DeadEndSteps)
+	}
+
+	// Terminating at ship clears every finding.
+	rs.Rules = append(rs.Rules, Rule{
+		RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "ship"}},
+		RuleActions:  RuleActions{Tasks: []string{"ship"}, Properties: map[string]string{doneProp: "true"}},
+	})
+	report = VerifyWorkflowCompleteness(rs, *schema)
+	if !report.OK() {
+		t.Errorf("completed workflow still flagged: %+v", report)
+	}
+}
+
+func TestVerifyWorkflowCompletenessUnusedAndCycle(t *testing.T) {
+	schema := wfOrderSchema()
+	defer setupTestSchema(t, schema, nil)()
+	rs := RuleSet{
+		Class:   "order",
+		SetName: "orderflow",
+		Rules: []Rule{
+			{
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: startStep}},
+				RuleActions:  RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{nextStepProp: "approve"}},
+			},
+			{
+				// approve loops back to itself with no exit.
+				RulePatterns: []RulePatternTerm{{Attr: stepAttr, Op: opEQ, Val: "approve"}},
+				RuleActions:  RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{nextStepProp: "approve"}},
+			},
+		},
+	}
+	report := VerifyWorkflowCompleteness(rs, *schema)
+	if !reflect.DeepEqual(report.CyclesWithoutExit, []string{"approve"}) {
+		t.Errorf("CyclesWithoutExit = %v, want [approve]", report.CyclesWithoutExit)
+	}
+	if !reflect.DeepEqual(report.UnusedSteps, []string{"ship"}) {
+		t.Errorf("UnusedSteps = %v, want [ship]", report.UnusedSteps)
+	}
+	if !reflect.DeepEqual(report.UnusedTasks, []string{"ship"}) {
+		t.Errorf("UnusedTasks = %v, want [ship]", report.UnusedTasks)
+	}
+}
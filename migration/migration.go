@@ -0,0 +1,87 @@
+// Package migration runs large realm imports as a sequence of
+// checkpointed items, so an interrupted migration can resume where it
+// stopped instead of starting over.
+package migration
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Item is one unit of a realm migration: an identifier stable across
+// runs and the action that applies it. Apply must be safe to skip once
+// it has succeeded.
+type Item struct {
+	ID    string
+	Apply func() error
+}
+
+// Runner applies a realm's migration items in order, checkpointing each
+// success so a resume after cancellation or failure skips the items
+// already applied.
+type Runner struct {
+	mu       sync.Mutex
+	realm    string
+	items    []Item
+	applied  map[string]bool
+	canceled bool
+	lastErr  string
+}
+
+// NewRunner prepares a migration of the given items for a realm.
+func NewRunner(realm string, items []Item) *Runner {
+	return &Runner{realm: realm, items: items, applied: map[string]bool{}}
+}
+
+// Run applies every item not yet checkpointed, in order. It stops at the
+// first failure or cancellation, leaving the checkpoints of the items
+// that succeeded, and may be called again to resume.
+func (r *Runner) Run() error {
+	r.mu.Lock()
+	r.canceled = false
+	r.lastErr = ""
+	r.mu.Unlock()
+	for i := range r.items {
+		item := &r.items[i]
+		r.mu.Lock()
+		skip := r.applied[item.ID]
+		canceled := r.canceled
+		r.mu.Unlock()
+		if canceled {
+			return fmt.Errorf("migration of realm %q canceled at item %q", r.realm, item.ID)
+		}
+		if skip {
+			continue
+		}
+		if err := item.Apply(); err != nil {
+			r.mu.Lock()
+			r.lastErr = err.Error()
+			r.mu.Unlock()
+			return fmt.Errorf("migration of realm %q failed at item %q: %v", r.realm, item.ID, err)
+		}
+		r.mu.Lock()
+		r.applied[item.ID] = true
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// Cancel stops the run before its next item; applied checkpoints stay.
+func (r *Runner) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.canceled = true
+}
+
+// Progress reports how many items have been applied out of the total,
+// along with the last error if the previous run failed.
+func (r *Runner) Progress() (applied, total int, lastErr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.applied), len(r.items), r.lastErr
+}
+
+// Realm returns the realm this migration targets.
+func (r *Runner) Realm() string {
+	return r.realm
+}
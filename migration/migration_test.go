@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+)
+
+// A migration interrupted by a failing item resumes from its checkpoint:
+// already-applied items are not re-applied, and the fixed item plus the
+// rest complete exactly once.
+func TestResumeSkipsAppliedItems(t *testing.T) {
+	applies := map[string]int{}
+	failing := true
+	items := []Item{
+		{ID: "schema-1", Apply: func() error { applies["schema-1"]++; return nil }},
+		{ID: "ruleset-1", Apply: func() error {
+			applies["ruleset-1"]++
+			if failing {
+				return errors.New("db timeout")
+			}
+			return nil
+		}},
+		{ID: "ruleset-2", Apply: func() error { applies["ruleset-2"]++; return nil }},
+	}
+	r := NewRunner("acme", items)
+
+	if err := r.Run(); err == nil {
+		t.Fatalf("run succeeded despite failing item")
+	}
+	applied, total, lastErr := r.Progress()
+	if applied != 1 || total != 3 || lastErr == "" {
+		t.Fatalf("Progress after interruption = (%d, %d, %q)", applied, total, lastErr)
+	}
+
+	failing = false
+	if err := r.Run(); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	applied, _, lastErr = r.Progress()
+	if applied != 3 || lastErr != "" {
+		t.Fatalf("Progress after resume = (%d, %q)", applied, lastErr)
+	}
+	if applies["schema-1"] != 1 {
+		t.Fatalf("already-applied item re-applied %d times", applies["schema-1"])
+	}
+	if applies["ruleset-1"] != 2 || applies["ruleset-2"] != 1 {
+		t.Fatalf("unexpected apply counts: %v", applies)
+	}
+}
+
+// Cancel stops the run at the next item but keeps the checkpoints.
+func TestCancelKeepsCheckpoints(t *testing.T) {
+	var r *Runner
+	items := []Item{
+		{ID: "a", Apply: func() error { r.Cancel(); return nil }},
+		{ID: "b", Apply: func() error { return nil }},
+	}
+	r = NewRunner("acme", items)
+	if err := r.Run(); err == nil {
+		t.Fatalf("canceled run reported success")
+	}
+	applied, _, _ := r.Progress()
+	if applied != 1 {
+		t.Fatalf("applied = %d after cancel, want 1", applied)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// A route registered without a declared capability is default-denied; a
+// declared capability gates the route on the caller's header, and
+// capPublic opts out explicitly.
+func TestHandleDefaultDeny(t *testing.T) {
+	s := &Server{mux: http.NewServeMux()}
+	ok := func(w http.ResponseWriter, r *http.Request) { writeSuccess(w, "ok") }
+	s.handle("/undeclared", "", ok)
+	s.handle("/gated", "reports", ok)
+	s.handle("/open", capPublic, ok)
+
+	get := func(path, caps string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if caps != "" {
+			r.Header.Set(capabilitiesHeader, caps)
+		}
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := get("/undeclared", "reports,admin"); code != http.StatusUnauthorized {
+		t.Errorf("undeclared route: got %d, want 401 regardless of capabilities", code)
+	}
+	if code := get("/gated", ""); code != http.StatusUnauthorized {
+		t.Errorf("gated route without capability: got %d, want 401", code)
+	}
+	if code := get("/gated", "reports"); code != http.StatusOK {
+		t.Errorf("gated route with capability: got %d, want 200", code)
+	}
+	if code := get("/open", ""); code != http.StatusOK {
+		t.Errorf("public route: got %d, want 200", code)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleRuleMatchBatch serves POST /rulematchbatch: a ruleset name and
+// an array of entities, evaluated in one request. Entities that fail
+// verification are reported in their own result slots; only a malformed
+// payload or an unknown ruleset fails the whole request. Successful
+// results are persisted under the request's correlation ID for later
+// retrieval via /evaluationresult.
+func (s *Server) handleRuleMatchBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "evaluate") {
+		writeErr(w, http.StatusForbidden, "evaluate capability required")
+		return
+	}
+	var req struct {
+		SetName       string            `json:"setname"`
+		Entities      []json.RawMessage `json:"entities"`
+		CheckRequired bool              `json:"checkrequired"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.SetName == "" {
+		writeErr(w, http.StatusBadRequest, "missing setname")
+		return
+	}
+	if len(req.Entities) == 0 {
+		writeErr(w, http.StatusBadRequest, "missing entities")
+		return
+	}
+	entities := make([]crux.Entity, len(req.Entities))
+	for i, raw := range req.Entities {
+		entity, err := crux.LoadEntityJSON(raw, crux.DupKeyError)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, fmt.Sprintf("entity %d: %v", i, err))
+			return
+		}
+		entities[i] = entity
+	}
+	opts := crux.MatchOptions{
+		CorrelationID: RequestID(r.Context()),
+		CheckRequired: req.CheckRequired,
+	}
+	results, failures, err := crux.MatchBatchWithOptions(r.Context(), req.SetName, entities, opts)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.persistResults(opts.CorrelationID, results)
+	writeSuccess(w, map[string]any{"results": results, "failures": failures})
+}
+
+// persistResults stores each successful result of a batch in the result
+// store: a one-entity batch under the correlation ID itself, a larger
+// batch under "<id>#<index>" per entity.
+func (s *Server) persistResults(id string, results []crux.BatchEntityResult) {
+	if id == "" {
+		return
+	}
+	for i := range results {
+		if results[i].ActionSet == nil {
+			continue
+		}
+		key := id
+		if len(results) > 1 {
+			key = fmt.Sprintf("%s#%d", id, i)
+		}
+		s.results.put(key, *results[i].ActionSet)
+	}
+}
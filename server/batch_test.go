@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// A batch request returns a result per entity: entities that fail
+// verification get individual errors and count toward the aggregate
+// failure count, without aborting the valid ones.
+func TestRuleMatchBatch(t *testing.T) {
+	schema := &crux.RuleSchema{
+		Class: "batchorders",
+		PatternSchema: []crux.AttrSchema{
+			{Name: "amount", ValType: "float"},
+		},
+		ActionSchema: crux.ActionSchema{Tasks: []string{"approve"}},
+	}
+	if err := crux.SaveRuleSchema(schema, false); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+	crux.ReplaceRuleSet(&crux.RuleSet{
+		Id:      1,
+		Class:   "batchorders",
+		SetName: "batchset",
+		Rules: []crux.Rule{{
+			RulePatterns: []crux.RulePatternTerm{{Attr: "amount", Op: "gt", Val: 100.0}},
+			RuleActions:  crux.RuleActions{Tasks: []string{"approve"}},
+		}},
+	})
+
+	s := New()
+	body := `{"setname":"batchset","entities":[
+		{"class":"batchorders","attrs":{"amount":"150"}},
+		{"class":"batchorders","attrs":{"amount":"notanumber"}},
+		{"class":"batchorders","attrs":{"amount":"50"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/rulematchbatch", strings.NewReader(body))
+	req.Header.Set(capabilitiesHeader, "evaluate")
+	req.Header.Set(requestIDHeader, "batch-req-1")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Results  []crux.BatchEntityResult `json:"results"`
+			Failures int                      `json:"failures"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Failures != 1 || len(resp.Data.Results) != 3 {
+		t.Fatalf("unexpected response: %+v", resp.Data)
+	}
+	if resp.Data.Results[0].ActionSet == nil || len(resp.Data.Results[0].ActionSet.Tasks) != 1 {
+		t.Errorf("entity 0 should have matched: %+v", resp.Data.Results[0])
+	}
+	if resp.Data.Results[1].Error == "" {
+		t.Errorf("entity 1 should have failed verification")
+	}
+	if resp.Data.Results[2].ActionSet == nil || len(resp.Data.Results[2].ActionSet.Tasks) != 0 {
+		t.Errorf("entity 2 should have matched nothing: %+v", resp.Data.Results[2])
+	}
+
+	// Results persist under the request ID for later retrieval; with
+	// several entities in the batch the key carries the entity index.
+	req = httptest.NewRequest(http.MethodGet, "/evaluationresult?id=batch-req-1%230", nil)
+	req.Header.Set(capabilitiesHeader, "evaluate")
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "approve") {
+		t.Errorf("persisted result not retrievable: status %d body %s", rec.Code, rec.Body.String())
+	}
+
+	// Missing capability and unknown ruleset are request-level failures.
+	req = httptest.NewRequest(http.MethodPost, "/rulematchbatch", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without capability, got %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/rulematchbatch",
+		strings.NewReader(`{"setname":"nosuchset","entities":[{"class":"batchorders"}]}`))
+	req.Header.Set(capabilitiesHeader, "evaluate")
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown ruleset, got %d", rec.Code)
+	}
+}
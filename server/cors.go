@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORSConfig says which browser origins may call the API and how.
+// Origins are matched exactly, except the single entry "*" which allows
+// any origin (and is refused for credentialed setups, where the
+// browser requires a concrete origin echo).
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+}
+
+// SetCORS installs the CORS policy. The list of origins comes from
+// deployment configuration; nothing is allowed until it is set.
+func (s *Server) SetCORS(cfg CORSConfig) {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut}
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = []string{"Content-Type", capabilitiesHeader, requestIDHeader}
+	}
+	s.cors = &cfg
+}
+
+// withCORS wraps a handler with origin checks and preflight handling.
+// Requests without an Origin header, or with one the policy does not
+// allow, pass through untouched — the browser enforces the absence of
+// the response headers.
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if s.cors == nil || origin == "" || !s.corsOriginAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+		if s.cors.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", strings.Join(s.cors.AllowedMethods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(s.cors.AllowedHeaders, ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsOriginAllowed applies the origin policy; "*" only counts for
+// non-credentialed setups.
+func (s *Server) corsOriginAllowed(origin string) bool {
+	for _, allowed := range s.cors.AllowedOrigins {
+		if allowed == origin {
+			return true
+		}
+		if allowed == "*" && !s.cors.AllowCredentials {
+			return true
+		}
+	}
+	return false
+}
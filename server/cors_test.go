@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// A configured origin gets CORS headers and preflight answers; an
+// unlisted origin gets neither.
+func TestCORS(t *testing.T) {
+	s := New()
+	s.SetCORS(CORSConfig{AllowedOrigins: []string{"https://authoring.example"}, AllowCredentials: true})
+
+	req := httptest.NewRequest(http.MethodOptions, "/rulesetget", nil)
+	req.Header.Set("Origin", "https://authoring.example")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight: status %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://authoring.example" {
+		t.Errorf("origin not echoed: %v", rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("credentials not allowed: %v", rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("preflight missing allowed methods")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("unlisted origin allowed: %v", rec.Header())
+	}
+}
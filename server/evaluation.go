@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleEvaluationTrace serves GET /evaluationtrace?id=..., returning
+// the sampled trace of an earlier evaluation by its correlation ID.
+func (s *Server) handleEvaluationTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "missing id parameter")
+		return
+	}
+	tr, ok := crux.GetTrace(id)
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no sampled trace for this id")
+		return
+	}
+	writeSuccess(w, tr)
+}
+
+// handleEvaluationResult serves GET /evaluationresult?id=..., returning
+// the persisted result of an earlier evaluation by its correlation ID.
+func (s *Server) handleEvaluationResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "evaluate") {
+		writeErr(w, http.StatusForbidden, "evaluate capability required")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "missing id parameter")
+		return
+	}
+	actionSet, ok := s.results.get(id)
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no result for this id, or it has expired")
+		return
+	}
+	writeSuccess(w, actionSet)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleRuleMatchExplain serves POST /rulematchexplain: one entity
+// evaluated with the explain trace on, returning the action set plus
+// every rule touched in evaluation order. The trace costs an allocation
+// per rule, so it lives on its own opt-in route rather than in the
+// batch path.
+func (s *Server) handleRuleMatchExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "evaluate") {
+		writeErr(w, http.StatusForbidden, "evaluate capability required")
+		return
+	}
+	var req struct {
+		SetName string          `json:"setname"`
+		Entity  json.RawMessage `json:"entity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.SetName == "" || len(req.Entity) == 0 {
+		writeErr(w, http.StatusBadRequest, "missing setname or entity")
+		return
+	}
+	entity, err := crux.LoadEntityJSON(req.Entity, crux.DupKeyError)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	actionSet, steps, err := crux.MatchExplain(r.Context(), req.SetName, entity)
+	if err != nil {
+		writeErr(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]any{"actionset": actionSet, "steps": steps})
+}
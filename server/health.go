@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// readinessTimeout bounds the whole readiness probe, so a hung backend
+// turns into a prompt 503 instead of a hanging orchestrator check.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether one backing dependency is reachable.
+// It must respect the context's deadline.
+type ReadinessCheck func(ctx context.Context) error
+
+// RegisterReadinessCheck adds a named dependency probe to /readyz —
+// the database, a config store, whatever the deployment relies on.
+// Registration is expected at startup, before the server serves.
+func (s *Server) RegisterReadinessCheck(name string, check ReadinessCheck) {
+	if s.readiness == nil {
+		s.readiness = map[string]ReadinessCheck{}
+	}
+	s.readiness[name] = check
+}
+
+// handleHealthz serves GET /healthz: liveness only, 200 whenever the
+// process is up to answer at all.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeSuccess(w, map[string]string{"status": "up"})
+}
+
+// handleReadyz serves GET /readyz: every registered dependency probe
+// must pass within the readiness timeout, else 503 with the failures
+// named.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	results := map[string]string{}
+	ready := true
+	names := make([]string, 0, len(s.readiness))
+	for name := range s.readiness {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.readiness[name](ctx); err != nil {
+			results[name] = err.Error()
+			ready = false
+		} else {
+			results[name] = "ok"
+		}
+	}
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "error", "checks": results})
+		return
+	}
+	writeSuccess(w, map[string]any{"checks": results})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Liveness always answers 200; readiness follows the registered
+// dependency probes.
+func TestHealthEndpoints(t *testing.T) {
+	s := New()
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz: status %d", rec.Code)
+	}
+
+	s.RegisterReadinessCheck("db", func(ctx context.Context) error { return nil })
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("readyz with passing check: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	s.RegisterReadinessCheck("configstore", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz with failing check: status %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("failure not named in body: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleMetrics serves GET /metrics in the Prometheus text exposition
+// format. It renders the engine's counters on demand, so nothing is
+// spent between scrapes beyond the counters themselves.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var b strings.Builder
+	counters := crux.MetricsSnapshot()
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "# TYPE crux_%s counter\ncrux_%s %d\n", name, name, counters[name])
+	}
+
+	em := crux.EvalMetricsSnapshot()
+	sets := make([]string, 0, len(em.PerRuleSet))
+	for name := range em.PerRuleSet {
+		sets = append(sets, name)
+	}
+	sort.Strings(sets)
+	b.WriteString("# TYPE crux_evaluations_total counter\n")
+	for _, name := range sets {
+		fmt.Fprintf(&b, "crux_evaluations_total{ruleset=%q} %d\n", name, em.PerRuleSet[name].Evaluations)
+	}
+	b.WriteString("# TYPE crux_evaluations_matched_total counter\n")
+	for _, name := range sets {
+		fmt.Fprintf(&b, "crux_evaluations_matched_total{ruleset=%q} %d\n", name, em.PerRuleSet[name].Matched)
+	}
+	b.WriteString("# TYPE crux_evaluations_nomatch_total counter\n")
+	for _, name := range sets {
+		fmt.Fprintf(&b, "crux_evaluations_nomatch_total{ruleset=%q} %d\n", name, em.PerRuleSet[name].NoMatch)
+	}
+
+	b.WriteString("# TYPE crux_eval_duration_seconds histogram\n")
+	for i, le := range em.DurationBuckets {
+		fmt.Fprintf(&b, "crux_eval_duration_seconds_bucket{le=\"%g\"} %d\n", le, em.DurationCounts[i])
+	}
+	fmt.Fprintf(&b, "crux_eval_duration_seconds_bucket{le=\"+Inf\"} %d\n", em.DurationCount)
+	fmt.Fprintf(&b, "crux_eval_duration_seconds_sum %g\n", em.DurationSum)
+	fmt.Fprintf(&b, "crux_eval_duration_seconds_count %d\n", em.DurationCount)
+
+	fmt.Fprintf(&b, "# TYPE crux_thencall_depth_sum counter\ncrux_thencall_depth_sum %d\n", em.DepthSum)
+	fmt.Fprintf(&b, "# TYPE crux_thencall_depth_max gauge\ncrux_thencall_depth_max %d\n", em.DepthMax)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = w.Write([]byte(b.String()))
+}
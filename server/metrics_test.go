@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// After an evaluation, /metrics exposes per-ruleset counters and the
+// latency histogram in the Prometheus text format.
+func TestMetricsEndpoint(t *testing.T) {
+	s, id := stepWorkflow(t)
+	if rec := postStep(s, id, "approve"); rec.Code != http.StatusOK {
+		t.Fatalf("step: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`crux_evaluations_total{ruleset="steporderswf"}`,
+		"crux_eval_duration_seconds_bucket{le=\"+Inf\"}",
+		"crux_eval_duration_seconds_count",
+		"crux_ruleset_invocations",
+		"crux_thencall_depth_max",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/remiges-tech/crux/migration"
+)
+
+// RegisterMigration makes a realm's migration runner visible to the
+// progress and resume endpoints.
+func (s *Server) RegisterMigration(r *migration.Runner) {
+	s.migrations[r.Realm()] = r
+}
+
+// handleMigrationProgress serves GET /realmmigrationprogress?realm=X,
+// reporting how far a realm's migration has come.
+func (s *Server) handleMigrationProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	realm := r.URL.Query().Get("realm")
+	runner, ok := s.migrations[realm]
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no migration registered for realm")
+		return
+	}
+	applied, total, lastErr := runner.Progress()
+	writeSuccess(w, map[string]any{
+		"realm":   realm,
+		"applied": applied,
+		"total":   total,
+		"lasterr": lastErr,
+	})
+}
+
+// handleMigrationResume serves POST /realmmigrationresume, re-running a
+// realm's migration from its last checkpoint.
+func (s *Server) handleMigrationResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "migrate") {
+		writeErr(w, http.StatusForbidden, "migrate capability required")
+		return
+	}
+	var req struct {
+		Realm string `json:"realm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	runner, ok := s.migrations[req.Realm]
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no migration registered for realm")
+		return
+	}
+	if err := runner.Run(); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	applied, total, _ := runner.Progress()
+	writeSuccess(w, map[string]any{"realm": req.Realm, "applied": applied, "total": total})
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// The middleware honors a caller-supplied correlation ID and generates
+// one when the header is absent, echoing it either way.
+func TestRequestIDPropagation(t *testing.T) {
+	s := New()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set(requestIDHeader, "trace-me-42")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got != "trace-me-42" {
+		t.Errorf("supplied request ID not echoed: %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get(requestIDHeader); len(got) != 16 {
+		t.Errorf("generated request ID = %q, want 16 hex digits", got)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// defaultResultTTL is how long a persisted evaluation result stays
+// retrievable.
+const defaultResultTTL = 10 * time.Minute
+
+// storedResult is one persisted evaluation result.
+type storedResult struct {
+	ActionSet crux.ActionSet `json:"actionset"`
+	expiry    time.Time
+}
+
+// resultStore persists evaluation results keyed by the caller's
+// correlation/idempotency ID, with a TTL, so async consumers can fetch
+// the outcome of an earlier evaluation. Evaluation handlers store into
+// it whenever the caller supplies a correlation ID.
+type resultStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time // for tests
+	results map[string]storedResult
+}
+
+func newResultStore(ttl time.Duration) *resultStore {
+	if ttl <= 0 {
+		ttl = defaultResultTTL
+	}
+	return &resultStore{
+		ttl:     ttl,
+		now:     time.Now,
+		results: map[string]storedResult{},
+	}
+}
+
+// put persists a result under the correlation ID.
+func (rs *resultStore) put(id string, as crux.ActionSet) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.results[id] = storedResult{ActionSet: as, expiry: rs.now().Add(rs.ttl)}
+}
+
+// get retrieves a result if it is still within TTL, dropping it lazily
+// once expired.
+func (rs *resultStore) get(id string) (crux.ActionSet, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	res, ok := rs.results[id]
+	if !ok {
+		return crux.ActionSet{}, false
+	}
+	if rs.now().After(res.expiry) {
+		delete(rs.results, id)
+		return crux.ActionSet{}, false
+	}
+	return res.ActionSet, true
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+func TestResultStoreTTL(t *testing.T) {
+	s := New()
+	now := time.Now()
+	s.results.now = func() time.Time { return now }
+
+	s.results.put("corr-1", crux.ActionSet{Tasks: []string{"ship"}})
+
+	get := func() int {
+		r := httptest.NewRequest(http.MethodGet, "/evaluationresult?id=corr-1", nil)
+		r.Header.Set(capabilitiesHeader, "evaluate")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("within TTL: status = %d, want 200", code)
+	}
+
+	now = now.Add(defaultResultTTL + time.Second)
+	if code := get(); code != http.StatusNotFound {
+		t.Errorf("after TTL: status = %d, want 404", code)
+	}
+}
+
+func TestEvaluationResultNeedsCapability(t *testing.T) {
+	s := New()
+	r := httptest.NewRequest(http.MethodGet, "/evaluationresult?id=x", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403 without capability", w.Code)
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleRuleSetAttributes serves GET /rulesetattributes?setname=...,
+// returning the entity attributes the named ruleset (and everything it
+// chains to) references.
+func (s *Server) handleRuleSetAttributes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	setName := r.URL.Query().Get("setname")
+	if setName == "" {
+		writeErr(w, http.StatusBadRequest, "missing setname parameter")
+		return
+	}
+	rs, ok := crux.GetRuleSet(setName)
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no such ruleset")
+		return
+	}
+	writeSuccess(w, map[string]any{"attributes": crux.RequiredAttributes(*rs)})
+}
+
+// handleRuleSetRefCheck serves POST /rulesetrefcheck. The caller submits
+// a batch of proposed rulesets; the response lists every THENCALL or
+// ELSECALL that resolves neither within the batch nor among the rulesets
+// already loaded.
+func (s *Server) handleRuleSetRefCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		RuleSets []json.RawMessage `json:"rulesets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	candidates := make([]*crux.RuleSet, 0, len(req.RuleSets))
+	for _, raw := range req.RuleSets {
+		rs, err := crux.LoadRuleSetJSON(raw)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		candidates = append(candidates, rs)
+	}
+	writeSuccess(w, map[string]any{"dangling": crux.DoReferentialChecks(candidates)})
+}
+
+// handleRuleSetGet serves GET /rulesetget?setname=..., returning the
+// full stored ruleset — rules, patterns and actions — for the authoring
+// frontend.
+func (s *Server) handleRuleSetGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	setName := r.URL.Query().Get("setname")
+	if setName == "" {
+		writeErr(w, http.StatusBadRequest, "missing setname parameter")
+		return
+	}
+	rs, ok := crux.GetRuleSet(setName)
+	if !ok {
+		writeErr(w, http.StatusNotFound, "no such ruleset")
+		return
+	}
+	writeSuccess(w, rs)
+}
+
+// handleRuleSetNew serves POST /rulesetnew: validates and persists a
+// new ruleset. A set name already in use is rejected; validation
+// failures come back as the collected issues, with nothing written.
+func (s *Server) handleRuleSetNew(w http.ResponseWriter, r *http.Request) {
+	s.saveRuleSet(w, r, http.MethodPost, false)
+}
+
+// handleRuleSetUpdate serves PUT /rulesetupdate: validates and persists
+// a replacement for an existing ruleset, which must already be loaded.
+func (s *Server) handleRuleSetUpdate(w http.ResponseWriter, r *http.Request) {
+	s.saveRuleSet(w, r, http.MethodPut, true)
+}
+
+// saveRuleSet is the shared create/update path: method and capability
+// checks, full validation, then persistence only when everything holds.
+func (s *Server) saveRuleSet(w http.ResponseWriter, r *http.Request, method string, mustExist bool) {
+	if r.Method != method {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "admin") {
+		writeErr(w, http.StatusForbidden, "admin capability required")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "unreadable request body")
+		return
+	}
+	rs, err := crux.LoadRuleSetJSON(body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rs.SetName == "" {
+		writeErr(w, http.StatusBadRequest, "missing setname")
+		return
+	}
+	_, loaded := crux.GetRuleSet(rs.SetName)
+	if mustExist && !loaded {
+		writeErr(w, http.StatusNotFound, "no such ruleset")
+		return
+	}
+	if !mustExist && loaded {
+		writeErr(w, http.StatusConflict, "ruleset "+rs.SetName+" already exists")
+		return
+	}
+	isWF := r.URL.Query().Get("iswf") == "true"
+	if v := crux.ValidateRuleSet(rs, isWF); !v.OK {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"status": "error", "data": v})
+		return
+	}
+	if err := crux.ReplaceRuleSet(rs); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeSuccess(w, rs)
+}
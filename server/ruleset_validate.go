@@ -0,0 +1,31 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleRuleSetValidate serves POST /rulesetvalidate: a ruleset JSON in
+// the body, validated against its schema exactly as a save would,
+// without persisting anything. ?iswf=true applies the workflow checks.
+// Authoring UIs use it to iterate before creating the ruleset.
+func (s *Server) handleRuleSetValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "unreadable request body")
+		return
+	}
+	rs, err := crux.LoadRuleSetJSON(body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	isWF := r.URL.Query().Get("iswf") == "true"
+	writeSuccess(w, crux.ValidateRuleSet(rs, isWF))
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout is how long in-flight requests get to drain once a
+// termination signal arrives.
+const shutdownTimeout = 10 * time.Second
+
+// Run serves the API on addr until SIGINT or SIGTERM, then drains
+// in-flight requests within the shutdown timeout before returning.
+// Abruptly dropping the process mid-request can leave a workflow
+// instance half-transitioned; draining lets those writes finish.
+func (s *Server) Run(addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler()}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-sigCh:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
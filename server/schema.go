@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// defaultSchemaPageSize bounds a schema list response when the caller
+// does not supply a limit.
+const defaultSchemaPageSize = 50
+
+// Schema routes split reads from writes so a reporting integration can
+// hold schema_read without gaining the power to alter or delete schema
+// versions.
+const (
+	capSchemaRead  = "schema_read"
+	capSchemaWrite = "schema_write"
+)
+
+// handleSchemaList serves POST /schemalist: a page of the loaded
+// schemas, filtered by app and class substring, with the total count so
+// clients can page through hundreds of schemas without fetching them
+// all.
+// ?includeDeleted=true lets admins see tombstoned schemas too.
+func (s *Server) handleSchemaList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		App    string `json:"app"`
+		Class  string `json:"class"`
+		Limit  int    `json:"limit"`
+		Offset int    `json:"offset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSchemaPageSize
+	}
+	includeDeleted := r.URL.Query().Get("includeDeleted") == "true"
+	page, total := crux.ListSchemas(req.App, req.Class, req.Limit, req.Offset, includeDeleted)
+	writeSuccess(w, map[string]any{"schemas": page, "total": total})
+}
+
+// handleSchemaActive serves POST /schemaactive: toggles a schema
+// version's active flag. Deactivating a schema still referenced by
+// loaded rulesets fails with the holders named.
+func (s *Server) handleSchemaActive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, capSchemaWrite) {
+		writeErr(w, http.StatusForbidden, capSchemaWrite+" capability required")
+		return
+	}
+	var req struct {
+		Class   string `json:"class"`
+		Version int    `json:"version"`
+		Active  bool   `json:"active"`
+		IsWF    bool   `json:"iswf"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.Class == "" {
+		writeErr(w, http.StatusBadRequest, "missing class")
+		return
+	}
+	var err error
+	if req.Active {
+		err = crux.ActivateSchema(req.Class, req.Version, req.IsWF)
+	} else {
+		err = crux.DeactivateSchema(req.Class, req.Version)
+	}
+	if err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]any{"class": req.Class, "version": req.Version, "active": req.Active})
+}
+
+// handleSchemaDelete serves POST /schemadelete: soft-deletes a schema
+// version. Deleting a schema still referenced by loaded rulesets fails
+// with the holders named.
+func (s *Server) handleSchemaDelete(w http.ResponseWriter, r *http.Request) {
+	s.schemaTombstone(w, r, crux.DeleteSchema)
+}
+
+// handleSchemaRestore serves POST /schemarestore: clears a soft-deleted
+// schema version's tombstone.
+func (s *Server) handleSchemaRestore(w http.ResponseWriter, r *http.Request) {
+	s.schemaTombstone(w, r, crux.RestoreSchema)
+}
+
+// schemaTombstone is the shared shape of the delete and restore
+// handlers: POST, schema_write capability, class and version in the
+// body.
+func (s *Server) schemaTombstone(w http.ResponseWriter, r *http.Request, op func(string, int) error) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, capSchemaWrite) {
+		writeErr(w, http.StatusForbidden, capSchemaWrite+" capability required")
+		return
+	}
+	var req struct {
+		Class   string `json:"class"`
+		Version int    `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.Class == "" {
+		writeErr(w, http.StatusBadRequest, "missing class")
+		return
+	}
+	if err := op(req.Class, req.Version); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]any{"class": req.Class, "version": req.Version})
+}
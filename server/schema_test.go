@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Schema reads need schema_read and schema mutations need schema_write;
+// a caller holding only the read capability cannot reach a write
+// handler, and vice versa.
+func TestSchemaCapabilitySplit(t *testing.T) {
+	s := New()
+	call := func(path, caps, body string) int {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if caps != "" {
+			r.Header.Set(capabilitiesHeader, caps)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := call("/schemalist", "", "{}"); code != http.StatusUnauthorized {
+		t.Errorf("schemalist without capability: got %d, want 401", code)
+	}
+	if code := call("/schemalist", capSchemaWrite, "{}"); code != http.StatusUnauthorized {
+		t.Errorf("schemalist with only schema_write: got %d, want 401", code)
+	}
+	if code := call("/schemalist", capSchemaRead, "{}"); code != http.StatusOK {
+		t.Errorf("schemalist with schema_read: got %d, want 200", code)
+	}
+
+	for _, path := range []string{"/schemaactive", "/schemadelete", "/schemarestore"} {
+		if code := call(path, capSchemaRead, "{}"); code != http.StatusForbidden {
+			t.Errorf("%s with only schema_read: got %d, want 403", path, code)
+		}
+		// With the write capability the request clears the gate and
+		// fails on its empty body instead.
+		if code := call(path, capSchemaWrite, "{}"); code != http.StatusBadRequest {
+			t.Errorf("%s with schema_write: got %d, want 400 for missing class", path, code)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package server exposes the crux engine over HTTP.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/remiges-tech/crux/migration"
+	"github.com/remiges-tech/crux/wfinstance"
+	"github.com/remiges-tech/crux/wscutils"
+)
+
+// Server routes HTTP requests to the crux engine.
+type Server struct {
+	mux        *http.ServeMux
+	instances  *wfinstance.Store
+	results    *resultStore
+	migrations map[string]*migration.Runner
+	readiness  map[string]ReadinessCheck
+	cors       *CORSConfig
+}
+
+// New constructs a Server with all routes registered.
+func New() *Server {
+	s := &Server{
+		mux:        http.NewServeMux(),
+		instances:  wfinstance.NewStore(),
+		results:    newResultStore(defaultResultTTL),
+		migrations: map[string]*migration.Runner{},
+	}
+	s.routes()
+	return s
+}
+
+// Handler returns the root handler for mounting or serving, with the
+// request-logging middleware wrapped around every route.
+func (s *Server) Handler() http.Handler {
+	return withRequestLog(s.withCORS(s.mux))
+}
+
+// routes registers every endpoint with its capability declared. Routes
+// registered capPublic enforce per-method capabilities inside their
+// handler (reads are open, mutations gated).
+func (s *Server) routes() {
+	s.handle("/wfschemaexample", capPublic, s.handleWFSchemaExample)
+	s.handle("/wfinstanceundo", capPublic, s.handleWFInstanceUndo)
+	s.handle("/wfinstancestep", capPublic, s.handleWFInstanceStep)
+	s.handle("/wfinstanceabort", capPublic, s.handleWFInstanceAbort)
+	s.handle("/wfinstancehistory", capPublic, s.handleWFInstanceHistory)
+	s.handle("/wfinstanceretrytransition", capPublic, s.handleWFInstanceRetryTransition)
+	s.handle("/rulesetrefcheck", capPublic, s.handleRuleSetRefCheck)
+	s.handle("/rulesetattributes", capPublic, s.handleRuleSetAttributes)
+	s.handle("/evaluationresult", capPublic, s.handleEvaluationResult)
+	s.handle("/evaluationtrace", capPublic, s.handleEvaluationTrace)
+	s.handle("/realmverifyall", capPublic, s.handleRealmVerifyAll)
+	s.handle("/realmmigrationprogress", capPublic, s.handleMigrationProgress)
+	s.handle("/realmmigrationresume", capPublic, s.handleMigrationResume)
+	s.handle("/rulematchbatch", capPublic, s.handleRuleMatchBatch)
+	s.handle("/whynot", capPublic, s.handleWhyNot)
+	s.handle("/rulematchexplain", capPublic, s.handleRuleMatchExplain)
+	s.handle("/schemalist", capSchemaRead, s.handleSchemaList)
+	s.handle("/schemaactive", capPublic, s.handleSchemaActive)
+	s.handle("/schemadelete", capPublic, s.handleSchemaDelete)
+	s.handle("/schemarestore", capPublic, s.handleSchemaRestore)
+	s.handle("/rulesetvalidate", capPublic, s.handleRuleSetValidate)
+	s.handle("/rulesetget", capPublic, s.handleRuleSetGet)
+	s.handle("/rulesetnew", capPublic, s.handleRuleSetNew)
+	s.handle("/rulesetupdate", capPublic, s.handleRuleSetUpdate)
+	s.handle("/metrics", capPublic, s.handleMetrics)
+	s.handle("/healthz", capPublic, s.handleHealthz)
+	s.handle("/readyz", capPublic, s.handleReadyz)
+}
+
+// writeSuccess emits the standard success envelope.
+func writeSuccess(w http.ResponseWriter, data any) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "success", "data": data})
+}
+
+// writeValidationErr emits the error envelope with structured messages,
+// including JSON pointers for errors that identify a request-body field.
+func writeValidationErr(w http.ResponseWriter, code int, errs ...error) {
+	msgs := make([]wscutils.ErrorMessage, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, wscutils.NewErrorMessage(err))
+	}
+	writeJSON(w, code, map[string]any{"status": "error", "errors": msgs})
+}
+
+// writeErr emits the standard error envelope.
+func writeErr(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]any{"status": "error", "error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleRealmVerifyAll serves POST /realmverifyall, re-verifying every
+// loaded schema and the realm's rulesets so operators can spot objects
+// that a stricter engine build would reject before upgrading.
+func (s *Server) handleRealmVerifyAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "admin") {
+		writeErr(w, http.StatusForbidden, "admin capability required")
+		return
+	}
+	var req struct {
+		Realm string `json:"realm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	failures := crux.VerifyAll(req.Realm)
+	writeSuccess(w, map[string]any{
+		"failures": failures,
+		"clean":    len(failures) == 0,
+	})
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+	"github.com/remiges-tech/crux/wfinstance"
+)
+
+// handleWFInstanceUndo serves POST /wfinstanceundo, rolling a workflow
+// instance back over its most recent steps using the history trail.
+func (s *Server) handleWFInstanceUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "wfundo") {
+		writeErr(w, http.StatusForbidden, "wfundo capability required")
+		return
+	}
+	var req struct {
+		ID    string `json:"id"`
+		Steps int    `json:"steps"`
+		Force bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.ID == "" {
+		writeErr(w, http.StatusBadRequest, "missing instance id")
+		return
+	}
+	if err := s.instances.Undo(req.ID, req.Steps, req.Force); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	inst, err := s.instances.Get(req.ID)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeSuccess(w, inst)
+}
+
+// handleWFInstanceRetryTransition serves POST /wfinstanceretrytransition,
+// re-attempting a transition parked in the dead-letter store after a
+// transient failure.
+func (s *Server) handleWFInstanceRetryTransition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "wfretry") {
+		writeErr(w, http.StatusForbidden, "wfretry capability required")
+		return
+	}
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.ID == "" {
+		writeErr(w, http.StatusBadRequest, "missing dead-letter id")
+		return
+	}
+	if err := s.instances.RetryTransition(req.ID); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]string{"retried": req.ID})
+}
+
+// handleWFInstanceStep serves POST /wfinstancestep, advancing a workflow
+// instance after a task completes. The workflow ruleset is evaluated at
+// the instance's current step to find the pending tasks and the
+// nextstep/done properties; the submitted task must be among the pending
+// ones, and a completed instance cannot step again.
+func (s *Server) handleWFInstanceStep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "wfstep") {
+		writeErr(w, http.StatusForbidden, "wfstep capability required")
+		return
+	}
+	var req struct {
+		ID   string `json:"id"`
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.ID == "" || req.Task == "" {
+		writeErr(w, http.StatusBadRequest, "missing instance id or task")
+		return
+	}
+	inst, err := s.instances.Get(req.ID)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if inst.Completed {
+		writeErr(w, http.StatusConflict, "workflow instance "+inst.ID+" is completed")
+		return
+	}
+	if inst.Aborted {
+		writeErr(w, http.StatusConflict, "workflow instance "+inst.ID+" is aborted")
+		return
+	}
+	actionSet, err := crux.MatchWithOptions(r.Context(), inst.Workflow, stepEntity(inst),
+		crux.MatchOptions{CorrelationID: RequestID(r.Context())})
+	if err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	if !containsTask(actionSet.Tasks, req.Task) {
+		writeErr(w, http.StatusConflict, "task "+req.Task+" is not pending for instance "+inst.ID)
+		return
+	}
+	next := actionSet.Properties["nextstep"]
+	done := actionSet.Properties["done"] == "true"
+	if next == "" && !done {
+		writeErr(w, http.StatusConflict, "workflow ruleset set neither nextstep nor done at step "+inst.Step)
+		return
+	}
+	if done {
+		next = inst.Step
+	}
+	if err := s.instances.Advance(inst.ID, next, nil, done, req.Task, requestUser(r)); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	inst, err = s.instances.Get(inst.ID)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]any{"id": inst.ID, "step": inst.Step, "done": inst.Completed})
+}
+
+// handleWFInstanceAbort serves POST /wfinstanceabort, cancelling a
+// workflow instance administratively. The aborting principal comes from
+// the authenticated user the middleware forwarded; the reason from the
+// request body. Done and already-aborted instances refuse the abort.
+func (s *Server) handleWFInstanceAbort(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "wfabort") {
+		writeErr(w, http.StatusForbidden, "wfabort capability required")
+		return
+	}
+	actor := requestUser(r)
+	if actor == "" {
+		writeErr(w, http.StatusBadRequest, "no authenticated user on request")
+		return
+	}
+	var req struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.ID == "" {
+		writeErr(w, http.StatusBadRequest, "missing instance id")
+		return
+	}
+	if err := s.instances.Abort(req.ID, actor, req.Reason); err != nil {
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	inst, err := s.instances.Get(req.ID)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeSuccess(w, inst)
+}
+
+// handleWFInstanceHistory serves GET /wfinstancehistory?id=...,
+// returning the ordered transition trail of one instance.
+func (s *Server) handleWFInstanceHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, http.StatusBadRequest, "missing id parameter")
+		return
+	}
+	history, err := s.instances.History(id)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeSuccess(w, history)
+}
+
+// stepEntity builds the evaluation entity for an instance: its stored
+// attributes plus the current step.
+func stepEntity(inst *wfinstance.Instance) crux.Entity {
+	attrs := make(map[string]string, len(inst.Attrs)+1)
+	for k, v := range inst.Attrs {
+		attrs[k] = v
+	}
+	attrs["step"] = inst.Step
+	return crux.Entity{Realm: inst.Realm, App: inst.App, Class: inst.Class, Attrs: attrs}
+}
+
+func containsTask(tasks []string, task string) bool {
+	for _, t := range tasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// stepWorkflow loads a two-step workflow (START -> approve -> done) and
+// returns a server holding one fresh instance of it.
+func stepWorkflow(t *testing.T) (*Server, string) {
+	t.Helper()
+	schema := &crux.RuleSchema{
+		Class: "steporders",
+		PatternSchema: []crux.AttrSchema{
+			{Name: "step", ValType: "enum", Vals: map[string]struct{}{"START": {}, "approve": {}}},
+			{Name: "stepfailed", ValType: "bool"},
+			{Name: "amount", ValType: "float"},
+		},
+		ActionSchema: crux.ActionSchema{
+			Tasks:      []string{"approve", "ship"},
+			Properties: []string{"nextstep", "done"},
+		},
+	}
+	if err := crux.SaveRuleSchema(schema, true); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+	err := crux.ReplaceRuleSet(&crux.RuleSet{
+		Id:      1,
+		Class:   "steporders",
+		SetName: "steporderswf",
+		Rules: []crux.Rule{
+			{
+				RulePatterns: []crux.RulePatternTerm{{Attr: "step", Op: "eq", Val: "START"}},
+				RuleActions:  crux.RuleActions{Tasks: []string{"approve"}, Properties: map[string]string{"nextstep": "approve"}},
+			},
+			{
+				RulePatterns: []crux.RulePatternTerm{{Attr: "step", Op: "eq", Val: "approve"}},
+				RuleActions:  crux.RuleActions{Tasks: []string{"ship"}, Properties: map[string]string{"done": "true"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceRuleSet: %v", err)
+	}
+	s := New()
+	inst := s.instances.New("r1", "app1", "steporders", "steporderswf", map[string]string{"amount": "120", "stepfailed": "false"})
+	return s, inst.ID
+}
+
+// postStep submits one task completion and returns the recorder.
+func postStep(s *Server, id, task string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/wfinstancestep",
+		strings.NewReader(`{"id":"`+id+`","task":"`+task+`"}`))
+	req.Header.Set(capabilitiesHeader, "wfstep")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// Stepping an instance walks the workflow ruleset: only a pending task
+// is accepted, done is terminal, and further steps are refused.
+func TestWFInstanceStep(t *testing.T) {
+	s, id := stepWorkflow(t)
+
+	if rec := postStep(s, id, "ship"); rec.Code != http.StatusConflict {
+		t.Fatalf("non-pending task: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postStep(s, id, "approve"); rec.Code != http.StatusOK {
+		t.Fatalf("approve: status %d: %s", rec.Code, rec.Body.String())
+	}
+	inst, err := s.instances.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if inst.Step != "approve" || inst.Completed {
+		t.Fatalf("after approve: step %q completed %v", inst.Step, inst.Completed)
+	}
+
+	if rec := postStep(s, id, "ship"); rec.Code != http.StatusOK {
+		t.Fatalf("ship: status %d: %s", rec.Code, rec.Body.String())
+	}
+	inst, _ = s.instances.Get(id)
+	if !inst.Completed {
+		t.Fatalf("instance should be done after ship")
+	}
+	if rec := postStep(s, id, "ship"); rec.Code != http.StatusConflict {
+		t.Fatalf("step after done: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// Aborting an instance records actor and reason and freezes it; a
+// second abort and any further step are refused.
+func TestWFInstanceAbort(t *testing.T) {
+	s, id := stepWorkflow(t)
+
+	abort := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/wfinstanceabort",
+			strings.NewReader(`{"id":"`+id+`","reason":"duplicate order"}`))
+		req.Header.Set(capabilitiesHeader, "wfabort")
+		if user != "" {
+			req.Header.Set(userHeader, user)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := abort(""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("abort without user: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := abort("ops-lee"); rec.Code != http.StatusOK {
+		t.Fatalf("abort: status %d: %s", rec.Code, rec.Body.String())
+	}
+	inst, err := s.instances.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !inst.Aborted || inst.AbortedBy != "ops-lee" || inst.AbortNote != "duplicate order" {
+		t.Fatalf("abort not recorded: %+v", inst)
+	}
+	if rec := abort("ops-lee"); rec.Code != http.StatusConflict {
+		t.Fatalf("second abort: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postStep(s, id, "approve"); rec.Code != http.StatusConflict {
+		t.Fatalf("step after abort: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// The history endpoint returns the ordered trail with the task and
+// actor that drove each transition.
+func TestWFInstanceHistory(t *testing.T) {
+	s, id := stepWorkflow(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/wfinstancestep",
+		strings.NewReader(`{"id":"`+id+`","task":"approve"}`))
+	req.Header.Set(capabilitiesHeader, "wfstep")
+	req.Header.Set(userHeader, "clerk-1")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("step: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wfinstancehistory?id="+id, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("history: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data []struct {
+			Kind     string `json:"kind"`
+			FromStep string `json:"fromstep"`
+			ToStep   string `json:"tostep"`
+			Task     string `json:"task"`
+			Actor    string `json:"actor"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Data) != 1 {
+		t.Fatalf("history length = %d, want 1", len(resp.Data))
+	}
+	ev := resp.Data[0]
+	if ev.Kind != "advance" || ev.FromStep != "START" || ev.ToStep != "approve" ||
+		ev.Task != "approve" || ev.Actor != "clerk-1" {
+		t.Errorf("unexpected history event: %+v", ev)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wfinstancehistory?id=wfi-999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown instance: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleWFSchemaExample serves GET /wfschemaexample?class=..., returning
+// an example entity conforming to the class's schema.
+func (s *Server) handleWFSchemaExample(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	class := r.URL.Query().Get("class")
+	if class == "" {
+		writeErr(w, http.StatusBadRequest, "missing class parameter")
+		return
+	}
+	entity, err := crux.GenerateExampleEntity(class)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeSuccess(w, entity)
+}
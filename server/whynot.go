@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// handleWhyNot serves POST /whynot: given a ruleset, an entity and an
+// expected task, it reports why each rule that could have emitted the
+// task failed to contribute it.
+func (s *Server) handleWhyNot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !hasCapability(r, "evaluate") {
+		writeErr(w, http.StatusForbidden, "evaluate capability required")
+		return
+	}
+	var req struct {
+		SetName string          `json:"setname"`
+		Entity  json.RawMessage `json:"entity"`
+		Task    string          `json:"task"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "malformed request body")
+		return
+	}
+	if req.SetName == "" || req.Task == "" || len(req.Entity) == 0 {
+		writeErr(w, http.StatusBadRequest, "missing setname, entity or task")
+		return
+	}
+	entity, err := crux.LoadEntityJSON(req.Entity, crux.DupKeyError)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report, err := crux.WhyNotTask(req.SetName, entity, req.Task)
+	if err != nil {
+		writeErr(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeSuccess(w, map[string]any{"report": report})
+}
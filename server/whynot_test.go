@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	crux "github.com/remiges-tech/crux/matching-engine"
+)
+
+// The why-not endpoint explains which rule could have emitted the
+// expected task and why it did not.
+func TestWhyNotEndpoint(t *testing.T) {
+	schema := &crux.RuleSchema{
+		Class: "whynotorders",
+		PatternSchema: []crux.AttrSchema{
+			{Name: "amount", ValType: "float"},
+		},
+		ActionSchema: crux.ActionSchema{Tasks: []string{"approve"}},
+	}
+	if err := crux.SaveRuleSchema(schema, false); err != nil {
+		t.Fatalf("SaveRuleSchema: %v", err)
+	}
+	crux.ReplaceRuleSet(&crux.RuleSet{
+		Id:      1,
+		Class:   "whynotorders",
+		SetName: "whynotset",
+		Rules: []crux.Rule{{
+			RulePatterns: []crux.RulePatternTerm{{Attr: "amount", Op: "gt", Val: 100.0}},
+			RuleActions:  crux.RuleActions{Tasks: []string{"approve"}},
+		}},
+	})
+
+	s := New()
+	body := `{"setname":"whynotset","task":"approve","entity":{"class":"whynotorders","attrs":{"amount":"50"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/whynot", strings.NewReader(body))
+	req.Header.Set(capabilitiesHeader, "evaluate")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "pattern did not match") {
+		t.Errorf("report missing the pattern failure: %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/whynot", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without capability, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package wfinstance
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRetention is how long history is kept for realms without their
+// own retention policy.
+const DefaultRetention = 365 * 24 * time.Hour
+
+// SetRetention sets how long history of the realm's instances is kept;
+// instances and events older than this are removed by Compact. A zero
+// duration restores the default.
+func (st *Store) SetRetention(realm string, d time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if d <= 0 {
+		delete(st.retention, realm)
+		return
+	}
+	st.retention[realm] = d
+}
+
+// SetLegalHold marks or unmarks an instance as under legal hold.
+// Compact never touches a held instance, regardless of its age.
+func (st *Store) SetLegalHold(id string, hold bool) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return fmt.Errorf("no workflow instance %q", id)
+	}
+	inst.LegalHold = hold
+	return nil
+}
+
+// Compact applies each realm's retention policy as of the given time:
+// history events older than the window are dropped, and completed
+// instances whose entire trail has aged out are deleted. Instances under
+// legal hold are left intact. It returns the number of history events
+// removed, instances deleted included.
+func (st *Store) Compact(asOf time.Time) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	removed := 0
+	for id, inst := range st.instances {
+		if inst.LegalHold {
+			continue
+		}
+		cutoff := asOf.Add(-st.retentionFor(inst.Realm))
+		kept := inst.History[:0]
+		for i := range inst.History {
+			if inst.History[i].At.Before(cutoff) {
+				removed++
+				continue
+			}
+			kept = append(kept, inst.History[i])
+		}
+		inst.History = kept
+		if inst.Completed && len(inst.History) == 0 {
+			delete(st.instances, id)
+		}
+	}
+	return removed
+}
+
+// StartCompaction runs Compact on every tick of the given interval until
+// the returned stop function is called.
+func (st *Store) StartCompaction(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				st.Compact(now)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// retentionFor resolves the realm's retention window; callers hold st.mu.
+func (st *Store) retentionFor(realm string) time.Duration {
+	if d, ok := st.retention[realm]; ok {
+		return d
+	}
+	return DefaultRetention
+}
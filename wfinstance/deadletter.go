@@ -0,0 +1,85 @@
+package wfinstance
+
+import (
+	"fmt"
+	"time"
+)
+
+// DeadLetter records a workflow transition that failed for a transient
+// reason, with everything needed to re-attempt it. The instance itself
+// stays on its current step until the retry succeeds.
+type DeadLetter struct {
+	ID         string            `json:"id"`
+	InstanceID string            `json:"instanceid"`
+	ToStep     string            `json:"tostep"`
+	Attrs      map[string]string `json:"attrs"`
+	Completed  bool              `json:"completed"`
+	Error      string            `json:"error"`
+	At         time.Time         `json:"at"`
+}
+
+// RecordFailedTransition parks a transition that could not be applied —
+// typically a transient enrichment or storage failure — in the
+// dead-letter store, returning the dead letter's ID for later retry.
+func (st *Store) RecordFailedTransition(instanceID, toStep string, attrs map[string]string, completed bool, cause error) string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextDLID++
+	dl := &DeadLetter{
+		ID:         fmt.Sprintf("dl-%d", st.nextDLID),
+		InstanceID: instanceID,
+		ToStep:     toStep,
+		Attrs:      copyAttrs(attrs),
+		Completed:  completed,
+		Error:      cause.Error(),
+		At:         time.Now().UTC(),
+	}
+	st.deadLetters[dl.ID] = dl
+	return dl.ID
+}
+
+// GetDeadLetter returns a parked transition by ID.
+func (st *Store) GetDeadLetter(id string) (*DeadLetter, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	dl, ok := st.deadLetters[id]
+	if !ok {
+		return nil, fmt.Errorf("no dead-lettered transition %q", id)
+	}
+	return dl, nil
+}
+
+// DeadLetters lists the parked transitions of one instance, or all of
+// them when instanceID is empty.
+func (st *Store) DeadLetters(instanceID string) []*DeadLetter {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var out []*DeadLetter
+	for _, dl := range st.deadLetters {
+		if instanceID == "" || dl.InstanceID == instanceID {
+			out = append(out, dl)
+		}
+	}
+	return out
+}
+
+// RetryTransition re-attempts a parked transition. On success the dead
+// letter is removed; on failure it stays parked with the new error.
+func (st *Store) RetryTransition(id string) error {
+	st.mu.Lock()
+	dl, ok := st.deadLetters[id]
+	st.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no dead-lettered transition %q", id)
+	}
+	if err := st.Advance(dl.InstanceID, dl.ToStep, dl.Attrs, dl.Completed, "", ""); err != nil {
+		st.mu.Lock()
+		dl.Error = err.Error()
+		st.mu.Unlock()
+		return err
+	}
+	st.mu.Lock()
+	delete(st.deadLetters, id)
+	st.mu.Unlock()
+	return nil
+}
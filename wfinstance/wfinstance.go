@@ -0,0 +1,235 @@
+// Package wfinstance tracks running workflow instances: the entity
+// attributes they carry, the step they are on, and the history trail of
+// every transition.
+package wfinstance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// History event kinds.
+const (
+	EventAdvance = "advance"
+	EventUndo    = "undo"
+	EventAbort   = "abort"
+)
+
+// StartStep is the step every instance begins on.
+const StartStep = "START"
+
+// HistoryEvent records one transition of an instance. AttrsBefore is the
+// attribute snapshot taken just before the event applied, which is what
+// an undo restores.
+type HistoryEvent struct {
+	At          time.Time         `json:"at"`
+	Kind        string            `json:"kind"`
+	FromStep    string            `json:"fromstep"`
+	ToStep      string            `json:"tostep"`
+	Task        string            `json:"task,omitempty"`
+	Actor       string            `json:"actor,omitempty"`
+	AttrsBefore map[string]string `json:"-"`
+}
+
+// Instance is one running workflow.
+type Instance struct {
+	ID        string            `json:"id"`
+	Realm     string            `json:"realm"`
+	App       string            `json:"app"`
+	Class     string            `json:"class"`
+	Workflow  string            `json:"workflow"`
+	Step      string            `json:"step"`
+	Attrs     map[string]string `json:"attrs"`
+	Completed bool              `json:"completed"`
+	Aborted   bool              `json:"aborted"`
+	AbortedBy string            `json:"abortedby,omitempty"`
+	AbortNote string            `json:"abortnote,omitempty"`
+	LegalHold bool              `json:"legalhold"`
+	History   []HistoryEvent    `json:"history"`
+}
+
+// Store keeps instances in memory, keyed by ID.
+type Store struct {
+	mu          sync.Mutex
+	instances   map[string]*Instance
+	nextID      int
+	retention   map[string]time.Duration
+	deadLetters map[string]*DeadLetter
+	nextDLID    int
+}
+
+// NewStore returns an empty instance store.
+func NewStore() *Store {
+	return &Store{instances: map[string]*Instance{}, retention: map[string]time.Duration{}, deadLetters: map[string]*DeadLetter{}}
+}
+
+// New creates an instance at the start step and returns it.
+func (st *Store) New(realm, app, class, workflow string, attrs map[string]string) *Instance {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.nextID++
+	inst := &Instance{
+		ID:       fmt.Sprintf("wfi-%d", st.nextID),
+		Realm:    realm,
+		App:      app,
+		Class:    class,
+		Workflow: workflow,
+		Step:     StartStep,
+		Attrs:    copyAttrs(attrs),
+	}
+	st.instances[inst.ID] = inst
+	return inst
+}
+
+// Get returns the instance with the given ID.
+func (st *Store) Get(id string) (*Instance, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("no workflow instance %q", id)
+	}
+	return inst, nil
+}
+
+// Advance moves an instance to a new step, updating its attributes and
+// recording the transition with a snapshot of the prior state. The task
+// whose completion drove the transition and the acting principal go
+// into the history record; both may be empty for system-driven moves.
+// The history append happens under the same lock as the step update, so
+// the two cannot diverge.
+func (st *Store) Advance(id, toStep string, attrs map[string]string, completed bool, task, actor string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return fmt.Errorf("no workflow instance %q", id)
+	}
+	if inst.Completed {
+		return fmt.Errorf("workflow instance %q is completed", id)
+	}
+	if inst.Aborted {
+		return fmt.Errorf("workflow instance %q is aborted", id)
+	}
+	inst.History = append(inst.History, HistoryEvent{
+		At:          time.Now().UTC(),
+		Kind:        EventAdvance,
+		FromStep:    inst.Step,
+		ToStep:      toStep,
+		Task:        task,
+		Actor:       actor,
+		AttrsBefore: copyAttrs(inst.Attrs),
+	})
+	inst.Step = toStep
+	if attrs != nil {
+		inst.Attrs = copyAttrs(attrs)
+	}
+	inst.Completed = completed
+	return nil
+}
+
+// Undo rolls an instance back over its most recent advance events,
+// restoring the step and attribute snapshot from before each one and
+// recording the rollback as history events of kind undo. Undo refuses to
+// roll back past the start step, and refuses to touch a completed
+// instance unless force is set.
+func (st *Store) Undo(id string, steps int, force bool) error {
+	if steps < 1 {
+		steps = 1
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return fmt.Errorf("no workflow instance %q", id)
+	}
+	if inst.Completed && !force {
+		return fmt.Errorf("workflow instance %q is completed; undo needs force", id)
+	}
+	if inst.Aborted && !force {
+		return fmt.Errorf("workflow instance %q is aborted; undo needs force", id)
+	}
+	for ; steps > 0; steps-- {
+		last := lastAdvance(inst)
+		if last == nil {
+			return fmt.Errorf("workflow instance %q has no step left to undo", id)
+		}
+		inst.History = append(inst.History, HistoryEvent{
+			At:          time.Now().UTC(),
+			Kind:        EventUndo,
+			FromStep:    inst.Step,
+			ToStep:      last.FromStep,
+			AttrsBefore: copyAttrs(inst.Attrs),
+		})
+		inst.Step = last.FromStep
+		inst.Attrs = copyAttrs(last.AttrsBefore)
+		inst.Completed = false
+	}
+	return nil
+}
+
+// History returns a copy of an instance's ordered transition trail.
+func (st *Store) History(id string) ([]HistoryEvent, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("no workflow instance %q", id)
+	}
+	return append([]HistoryEvent(nil), inst.History...), nil
+}
+
+// Abort cancels an instance administratively, recording who aborted it
+// and why. An aborted instance refuses further advances. Aborting an
+// instance that is already done or already aborted is an error.
+func (st *Store) Abort(id, actor, reason string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	inst, ok := st.instances[id]
+	if !ok {
+		return fmt.Errorf("no workflow instance %q", id)
+	}
+	if inst.Completed {
+		return fmt.Errorf("workflow instance %q is completed; nothing to abort", id)
+	}
+	if inst.Aborted {
+		return fmt.Errorf("workflow instance %q is already aborted", id)
+	}
+	inst.History = append(inst.History, HistoryEvent{
+		At:          time.Now().UTC(),
+		Kind:        EventAbort,
+		FromStep:    inst.Step,
+		ToStep:      inst.Step,
+		AttrsBefore: copyAttrs(inst.Attrs),
+	})
+	inst.Aborted = true
+	inst.AbortedBy = actor
+	inst.AbortNote = reason
+	return nil
+}
+
+// lastAdvance finds the most recent advance event not already undone.
+func lastAdvance(inst *Instance) *HistoryEvent {
+	undone := 0
+	for i := len(inst.History) - 1; i >= 0; i-- {
+		switch inst.History[i].Kind {
+		case EventUndo:
+			undone++
+		case EventAdvance:
+			if undone == 0 {
+				return &inst.History[i]
+			}
+			undone--
+		}
+	}
+	return nil
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package wfinstance
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUndoRestoresStepAndAttrs(t *testing.T) {
+	st := NewStore()
+	inst := st.New("acme", "retail", "order", "orderflow", map[string]string{"amount": "100"})
+
+	if err := st.Advance(inst.ID, "approve", map[string]string{"amount": "100", "approver": "lee"}, false, "approve", "lee"); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if err := st.Advance(inst.ID, "ship", map[string]string{"amount": "100", "approver": "lee", "carrier": "dhl"}, false, "ship", "lee"); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+
+	if err := st.Undo(inst.ID, 1, false); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	inst, _ = st.Get(inst.ID)
+	if inst.Step != "approve" {
+		t.Errorf("step after undo = %q, want approve", inst.Step)
+	}
+	if _, ok := inst.Attrs["carrier"]; ok {
+		t.Errorf("attrs after undo still carry carrier: %v", inst.Attrs)
+	}
+	if last := inst.History[len(inst.History)-1]; last.Kind != EventUndo {
+		t.Errorf("undo not recorded in history, last event %q", last.Kind)
+	}
+
+	// A second undo lands back on START; a third has nothing to undo.
+	if err := st.Undo(inst.ID, 1, false); err != nil {
+		t.Fatalf("Undo to start: %v", err)
+	}
+	inst, _ = st.Get(inst.ID)
+	if inst.Step != StartStep {
+		t.Errorf("step = %q, want %q", inst.Step, StartStep)
+	}
+	if err := st.Undo(inst.ID, 1, false); err == nil {
+		t.Errorf("undo past start did not fail")
+	}
+}
+
+func TestUndoCompletedNeedsForce(t *testing.T) {
+	st := NewStore()
+	inst := st.New("acme", "retail", "order", "orderflow", map[string]string{"amount": "100"})
+	if err := st.Advance(inst.ID, "close", nil, true, "", ""); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if err := st.Undo(inst.ID, 1, false); err == nil {
+		t.Errorf("undo on completed instance succeeded without force")
+	}
+	if err := st.Undo(inst.ID, 1, true); err != nil {
+		t.Errorf("forced undo failed: %v", err)
+	}
+	inst, _ = st.Get(inst.ID)
+	if inst.Completed {
+		t.Errorf("instance still completed after forced undo")
+	}
+}
+
+// Compaction drops history beyond the realm's retention window and
+// deletes aged-out completed instances, but never touches an instance
+// under legal hold.
+func TestCompactRespectsRetentionAndLegalHold(t *testing.T) {
+	st := NewStore()
+	st.SetRetention("r1", 24*time.Hour)
+
+	old := st.New("r1", "app", "order", "orderflow", map[string]string{"step": StartStep})
+	if err := st.Advance(old.ID, "approve", nil, true, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	old.History[0].At = old.History[0].At.Add(-48 * time.Hour)
+	held := st.New("r1", "app", "order", "orderflow", map[string]string{"step": StartStep})
+	if err := st.Advance(held.ID, "approve", nil, true, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	held.History[0].At = held.History[0].At.Add(-48 * time.Hour)
+	if err := st.SetLegalHold(held.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	fresh := st.New("r1", "app", "order", "orderflow", map[string]string{"step": StartStep})
+	if err := st.Advance(fresh.ID, "approve", nil, false, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	removed := st.Compact(time.Now().UTC())
+	if removed != 1 {
+		t.Fatalf("Compact removed %d events, want 1", removed)
+	}
+	if _, err := st.Get(old.ID); err == nil {
+		t.Fatalf("aged-out completed instance survived compaction")
+	}
+	heldInst, err := st.Get(held.ID)
+	if err != nil {
+		t.Fatalf("held instance was compacted: %v", err)
+	}
+	if len(heldInst.History) != 1 {
+		t.Fatalf("held instance lost history: %d events", len(heldInst.History))
+	}
+	if _, err := st.Get(fresh.ID); err != nil {
+		t.Fatalf("incomplete instance was deleted: %v", err)
+	}
+}
+
+// A transition that fails transiently lands in the dead-letter store with
+// its input and error, leaves the instance in place, and can be retried
+// to completion later.
+func TestDeadLetterRetry(t *testing.T) {
+	st := NewStore()
+	inst := st.New("acme", "retail", "order", "orderflow", map[string]string{"amount": "100"})
+
+	dlID := st.RecordFailedTransition(inst.ID, "approve",
+		map[string]string{"amount": "100", "approver": "lee"}, false,
+		errors.New("enrichment timed out"))
+	got, err := st.Get(inst.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Step != StartStep {
+		t.Fatalf("instance moved despite failed transition: step %q", got.Step)
+	}
+	dl, err := st.GetDeadLetter(dlID)
+	if err != nil {
+		t.Fatalf("GetDeadLetter: %v", err)
+	}
+	if dl.ToStep != "approve" || dl.Error != "enrichment timed out" {
+		t.Fatalf("dead letter did not capture the attempt: %+v", dl)
+	}
+
+	if err := st.RetryTransition(dlID); err != nil {
+		t.Fatalf("RetryTransition: %v", err)
+	}
+	got, err = st.Get(inst.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Step != "approve" || got.Attrs["approver"] != "lee" {
+		t.Fatalf("retry did not apply the parked transition: step %q attrs %v", got.Step, got.Attrs)
+	}
+	if _, err := st.GetDeadLetter(dlID); err == nil {
+		t.Fatalf("dead letter survived a successful retry")
+	}
+}
@@ -0,0 +1,54 @@
+// Package wscutils holds the request/response envelope conventions
+// shared by all crux web services.
+package wscutils
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorMessage is one structured error in a web service response.
+// Pointer, when present, is an RFC 6901 JSON pointer locating the
+// offending field in the request body, so form-building clients can
+// highlight the right input.
+type ErrorMessage struct {
+	Msg     string `json:"msg"`
+	Field   string `json:"field,omitempty"`
+	Pointer string `json:"pointer,omitempty"`
+}
+
+// Pointered is implemented by errors that can locate the offending field
+// within the submitted document.
+type Pointered interface {
+	JSONPointerPath() []string
+}
+
+// NewErrorMessage builds an ErrorMessage from an error, attaching a JSON
+// pointer when the error identifies a field.
+func NewErrorMessage(err error) ErrorMessage {
+	em := ErrorMessage{Msg: err.Error()}
+	var p Pointered
+	if errors.As(err, &p) {
+		path := p.JSONPointerPath()
+		em.Pointer = JSONPointer(path)
+		if len(path) > 0 {
+			em.Field = path[len(path)-1]
+		}
+	}
+	return em
+}
+
+// JSONPointer renders a path as an RFC 6901 JSON pointer.
+func JSONPointer(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, seg := range path {
+		seg = strings.ReplaceAll(seg, "~", "~0")
+		seg = strings.ReplaceAll(seg, "/", "~1")
+		b.WriteString("/")
+		b.WriteString(seg)
+	}
+	return b.String()
+}